@@ -0,0 +1,110 @@
+package pwsafe
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+// EntryType tells if a record is a normal entry or a reference to another one
+type EntryType int
+
+// The record entry types
+const (
+	// NormalEntry a record holding its own data
+	NormalEntry EntryType = iota
+	// AliasEntry a record taking the password from its base entry
+	AliasEntry
+	// ShortcutEntry a record taking all the data but title, group and username from its base entry
+	ShortcutEntry
+)
+
+// maxReferenceDepth limits the chain of references followed when resolving a record
+const maxReferenceDepth = 8
+
+var (
+	aliasRegexp    = regexp.MustCompile(`^\[\[([0-9a-fA-F]{32})\]\]$`)
+	shortcutRegexp = regexp.MustCompile(`^\[~([0-9a-fA-F]{32})~\]$`)
+)
+
+// DanglingReferenceError is returned when the base entry of an alias or a shortcut does not exist
+type DanglingReferenceError struct {
+	Title string
+	UUID  [16]byte
+}
+
+func (e DanglingReferenceError) Error() string {
+	return fmt.Sprintf("the base entry %x of '%s' does not exist", e.UUID, e.Title)
+}
+
+// AliasReference Returns the password value of an alias of the given base record
+func AliasReference(base Record) string {
+	return fmt.Sprintf("[[%x]]", base.UUID)
+}
+
+// ShortcutReference Returns the password value of a shortcut of the given base record
+func ShortcutReference(base Record) string {
+	return fmt.Sprintf("[~%x~]", base.UUID)
+}
+
+// EntryType Returns the kind of the record and, for aliases and shortcuts, the base entry UUID
+func (r Record) EntryType() (EntryType, [16]byte) {
+	var id [16]byte
+	kind := NormalEntry
+
+	m := aliasRegexp.FindStringSubmatch(r.Password)
+	if m != nil {
+		kind = AliasEntry
+	} else if m = shortcutRegexp.FindStringSubmatch(r.Password); m != nil {
+		kind = ShortcutEntry
+	} else {
+		return NormalEntry, id
+	}
+
+	b, _ := hex.DecodeString(m[1])
+	copy(id[:], b)
+	return kind, id
+}
+
+// ResolveRecord Returns the record with the data of its base entry if it is an alias or a shortcut.
+// A DanglingReferenceError is returned if the base entry does not exist.
+func (db V3) ResolveRecord(record Record) (Record, error) {
+	res := record
+	current := record
+	for depth := 0; ; depth++ {
+		kind, id := current.EntryType()
+		if kind == NormalEntry {
+			break
+		}
+		if depth >= maxReferenceDepth {
+			return record, fmt.Errorf("too many references resolving '%s'", record.Title)
+		}
+
+		base, ok := db.GetRecordByUUID(id)
+		if !ok || base.UUID == record.UUID {
+			return record, DanglingReferenceError{Title: current.Title, UUID: id}
+		}
+
+		if kind == AliasEntry {
+			res.Password = base.Password
+		} else {
+			title, group, username, uuid := res.Title, res.Group, res.Username, res.UUID
+			res = base
+			res.Title, res.Group, res.Username, res.UUID = title, group, username, uuid
+		}
+		current = base
+	}
+
+	return res, nil
+}
+
+// DanglingReferences Returns the titles of the aliases and shortcuts whose base entry does not exist
+func (db V3) DanglingReferences() []string {
+	var res []string
+	for _, title := range db.List() {
+		if _, err := db.ResolveRecord(db.Records[title]); err != nil {
+			res = append(res, title)
+		}
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+package pwsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasAndShortcut(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "base", Group: "bank", Username: "john", Password: "s3cr3t", URL: "http://bank.com"})
+	base, _ := db.GetRecord("base")
+
+	db.SetRecord(Record{Title: "alias", Username: "jack", Password: AliasReference(base)})
+	db.SetRecord(Record{Title: "shortcut", Username: "jim", Password: ShortcutReference(base)})
+	db.SetRecord(Record{Title: "dangling", Password: "[[0123456789abcdef0123456789abcdef]]"})
+
+	alias, _ := db.GetRecord("alias")
+	kind, id := alias.EntryType()
+	assert.Equal(t, AliasEntry, kind)
+	assert.Equal(t, base.UUID, id)
+
+	resolved, err := db.ResolveRecord(alias)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", resolved.Password)
+	assert.Equal(t, "jack", resolved.Username)
+	assert.Equal(t, "", resolved.URL)
+
+	shortcut, _ := db.GetRecord("shortcut")
+	kind, _ = shortcut.EntryType()
+	assert.Equal(t, ShortcutEntry, kind)
+
+	resolved, err = db.ResolveRecord(shortcut)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", resolved.Password)
+	assert.Equal(t, "jim", resolved.Username)
+	assert.Equal(t, "http://bank.com", resolved.URL)
+	assert.Equal(t, "shortcut", resolved.Title)
+	assert.Equal(t, shortcut.UUID, resolved.UUID)
+
+	// normal entries are returned as they are
+	resolved, err = db.ResolveRecord(base)
+	assert.Nil(t, err)
+	assert.Equal(t, base, resolved)
+
+	dangling, _ := db.GetRecord("dangling")
+	_, err = db.ResolveRecord(dangling)
+	assert.IsType(t, DanglingReferenceError{}, err)
+	assert.Equal(t, []string{"dangling"}, db.DanglingReferences())
+}
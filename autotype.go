@@ -0,0 +1,121 @@
+package pwsafe
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAutotype is the sequence used by Password Safe when a record has no Autotype
+const DefaultAutotype = `\u\t\p\n`
+
+// AutotypeAction is the kind of an autotype step
+type AutotypeAction int
+
+// The autotype step kinds
+const (
+	// AutotypeText the step Text has to be typed
+	AutotypeText AutotypeAction = iota
+	// AutotypeKey the step Text is the name of a key to press (Tab, Enter...)
+	AutotypeKey
+	// AutotypeDelay the step asks to wait for Delay
+	AutotypeDelay
+)
+
+// AutotypeStep is a single step of an autotype sequence
+type AutotypeStep struct {
+	Action AutotypeAction
+	Text   string
+	Delay  time.Duration
+}
+
+// AutotypeSteps Returns the steps of the record autotype sequence,
+// consecutive fields and literals are merged in a single text step.
+// The codes follow the Password Safe semantics:
+//
+//	\u username, \p password, \g group, \i title, \l URL, \m email, \o notes, \2 two factor code,
+//	\t Tab, \s Shift+Tab, \n and \r Enter, \b Backspace,
+//	\wNNN wait NNN milliseconds, \WNNN wait NNN seconds, \dNNN (typing delay, ignored)
+func (r Record) AutotypeSteps(now time.Time) []AutotypeStep {
+	sequence := r.Autotype
+	if strings.TrimSpace(sequence) == "" {
+		sequence = DefaultAutotype
+	}
+
+	var steps []AutotypeStep
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			steps = append(steps, AutotypeStep{Action: AutotypeText, Text: text.String()})
+			text.Reset()
+		}
+	}
+	key := func(name string) {
+		flush()
+		steps = append(steps, AutotypeStep{Action: AutotypeKey, Text: name})
+	}
+
+	runes := []rune(sequence)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i == len(runes)-1 {
+			text.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case 'u':
+			text.WriteString(r.Username)
+		case 'p':
+			text.WriteString(r.Password)
+		case 'g':
+			text.WriteString(r.Group)
+		case 'i':
+			text.WriteString(r.Title)
+		case 'l':
+			text.WriteString(r.URL)
+		case 'm':
+			text.WriteString(r.Email)
+		case 'o':
+			text.WriteString(r.Notes)
+		case '2':
+			if code, _, err := r.OTP(now); err == nil {
+				text.WriteString(code)
+			}
+		case 't':
+			key("Tab")
+		case 's':
+			key("Shift+Tab")
+		case 'n', 'r':
+			key("Enter")
+		case 'b':
+			key("Backspace")
+		case 'w', 'W', 'd':
+			code := runes[i]
+			j := i + 1
+			for j < len(runes) && j-i <= 3 && runes[j] >= '0' && runes[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(string(runes[i+1 : j]))
+			i = j - 1
+			if code == 'd' {
+				continue
+			}
+			unit := time.Millisecond
+			if code == 'W' {
+				unit = time.Second
+			}
+			flush()
+			steps = append(steps, AutotypeStep{Action: AutotypeDelay, Delay: time.Duration(n) * unit})
+		default:
+			// '\\' and unknown codes are typed as they are
+			if runes[i] != '\\' {
+				text.WriteRune('\\')
+			}
+			text.WriteRune(runes[i])
+		}
+	}
+	flush()
+
+	return steps
+}
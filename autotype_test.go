@@ -0,0 +1,31 @@
+package pwsafe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutotypeSteps(t *testing.T) {
+	record := Record{Title: "site", Username: "john", Password: "s3cr3t", Email: "john@doe.com"}
+
+	// the default sequence
+	steps := record.AutotypeSteps(time.Now())
+	assert.Equal(t, []AutotypeStep{
+		{Action: AutotypeText, Text: "john"},
+		{Action: AutotypeKey, Text: "Tab"},
+		{Action: AutotypeText, Text: "s3cr3t"},
+		{Action: AutotypeKey, Text: "Enter"},
+	}, steps)
+
+	record.Autotype = `\u@corp\w250\d10\p\\x\q\W2\n`
+	steps = record.AutotypeSteps(time.Now())
+	assert.Equal(t, []AutotypeStep{
+		{Action: AutotypeText, Text: "john@corp"},
+		{Action: AutotypeDelay, Delay: 250 * time.Millisecond},
+		{Action: AutotypeText, Text: `s3cr3t\x\q`},
+		{Action: AutotypeDelay, Delay: 2 * time.Second},
+		{Action: AutotypeKey, Text: "Enter"},
+	}, steps)
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/cli"
+	"github.com/lucasepe/tablewriter"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type auditAction struct {
+	checks   string
+	minScore int
+	maxAge   string
+	breachDB string
+	asJSON   bool
+	filename string
+}
+
+// report is the JSON output of the audit
+type report struct {
+	Records int            `json:"records"`
+	Issues  []issue        `json:"issues"`
+	Summary map[string]int `json:"summary"`
+}
+
+const (
+	cmdName   = "audit"
+	shortDesc = "report weak, reused, old and expired passwords"
+	longDesc  = `Check the health of the passwords of the store.
+
+Usage: %s %s [-checks weak,reused,...] [-min-score 3] [-max-age 1y] [-breach-db <file>] [-json]
+
+ * the checks are:
+   weak      the estimated strength is lower than -min-score (0 to 4, default 3)
+   reused    the same password is used by other records
+   username  the password is the username
+   title     the password is the title
+   old       the password was changed more than -max-age ago (default 1y)
+   url       the record has no URL
+   expired   the password expiry date has passed
+   breached  the password appears in the -breach-db file
+ * with -checks only the specified checks are run
+ * the strength is estimated in the manner of zxcvbn, looking for common passwords,
+   sequences, repeats and keyboard patterns, the username and the title too
+ * -breach-db is the Have I Been Pwned "SHA-1 ordered by hash" passwords file,
+   searched offline: the passwords never leave this computer. The first time an
+   index of the file is built in the user cache directory, so that the next
+   audits are fast
+ * aliases and shortcuts are skipped, they share the password of their base entry
+ * the records without a password are skipped, as are the secure notes, cards and
+   identities imported with the placeholder password '(none)'
+ * with -json the report is written as JSON
+ * the exit code is not zero when issues are found, so that it can be used by CI pipelines
+`
+)
+
+// NewAuditCommand create a 'audit' cli command
+func NewAuditCommand(filename string) *cli.Command {
+	action := auditAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+	}
+
+	return cmd
+}
+
+func (r *auditAction) handler() error {
+	names, err := parseChecks(r.checks, r.breachDB != "")
+	if err != nil {
+		return err
+	}
+
+	if r.minScore < 0 || r.minScore > 4 {
+		return fmt.Errorf("invalid minimum score %d - accepted values are from 0 to 4", r.minScore)
+	}
+
+	maxAge, err := utils.ParseDays(r.maxAge)
+	if err != nil {
+		return err
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	// the prompt goes to the terminal, the standard output holds only the report
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhraseFromTTY()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	a := &auditor{now: time.Now(), minScore: r.minScore, maxAge: maxAge}
+	if r.breachDB != "" {
+		if a.breaches, err = openBreachDB(r.breachDB); err != nil {
+			return err
+		}
+		defer a.breaches.Close()
+	}
+
+	titles := auditedTitles(db)
+	issues, err := a.run(db, titles, names)
+	if err != nil {
+		return err
+	}
+
+	if r.asJSON {
+		err = writeJSON(os.Stdout, len(titles), issues)
+	} else {
+		fmt.Println(dump(issues))
+		fmt.Println(summary(len(titles), issues, names))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+	return nil
+}
+
+func (r *auditAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.checks), "checks", "", "the comma separated checks to run, all if not specified")
+		fs.IntVar(&(r.minScore), "min-score", 3, "the minimum strength score (0-4) of the passwords")
+		fs.StringVar(&(r.maxAge), "max-age", "1y", "the maximum age of the passwords (i.e. 90d, 6w, 1y)")
+		fs.StringVar(&(r.breachDB), "breach-db", "", "the Have I Been Pwned SHA-1 ordered by hash passwords file")
+		fs.BoolVar(&(r.asJSON), "json", false, "write the report as JSON")
+	}
+}
+
+// auditedTitles returns the titles of the records holding a password, aliases and shortcuts excluded
+// as well as the imported items without one (i.e. secure notes)
+func auditedTitles(db pwsafe.DB) []string {
+	var res []string
+	for _, t := range db.List() {
+		rec, ok := db.GetRecord(t)
+		if !ok {
+			continue
+		}
+		if kind, _ := rec.EntryType(); kind != pwsafe.NormalEntry {
+			continue
+		}
+		if rec.Password == "" || rec.Password == pwsafe.PlaceholderPassword {
+			continue
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// dump renders the issues as a table
+func dump(issues []issue) string {
+	table := tablewriter.CreateTable()
+	table.Style = tablewriter.GhostStyle
+	table.AddHeaders("TITLE", "GROUP", "CHECK", "DETAIL")
+
+	for _, el := range issues {
+		table.AddRow(el.Title, el.Group, el.Check, utils.TruncateText(el.Detail, 61))
+	}
+
+	return table.Render()
+}
+
+// summary returns how many issues have been found by each check
+func summary(records int, issues []issue, names []string) string {
+	counts := countIssues(issues)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[name], name))
+	}
+	return fmt.Sprintf("%d records audited, %d issues: %s", records, len(issues), strings.Join(parts, ", "))
+}
+
+// writeJSON writes the report as JSON
+func writeJSON(w io.Writer, records int, issues []issue) error {
+	res := report{Records: records, Issues: issues, Summary: countIssues(issues)}
+	if res.Issues == nil {
+		res.Issues = []issue{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// countIssues returns the number of issues of each check
+func countIssues(issues []issue) map[string]int {
+	res := make(map[string]int)
+	for _, el := range issues {
+		res[el.Check]++
+	}
+	return res
+}
@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the index holds the offset of the first hash of each 16 bits prefix
+const (
+	indexMagic   = "PWSHIBP1"
+	indexBuckets = 1 << 16
+	indexSize    = len(indexMagic) + 16 + (indexBuckets+1)*8
+)
+
+// maxLineLength is the maximum length of a dataset line ("<40 hex chars>:<count>\r\n")
+const maxLineLength = 64
+
+// breachDB is a Have I Been Pwned ordered by hash SHA-1 passwords file,
+// searched with a binary search in the bucket of the hash prefix
+type breachDB struct {
+	f       *os.File
+	size    int64
+	offsets []int64 // the index, the start of each bucket followed by the file size
+}
+
+// openBreachDB opens the dataset, loading its index from the cache or building it
+func openBreachDB(fn string) (*breachDB, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	db := &breachDB{f: f, size: fi.Size()}
+	if err := db.checkFormat(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	cache := indexCachePath(fn)
+	if db.offsets = readIndex(cache, fi); db.offsets == nil {
+		fmt.Fprintf(os.Stderr, "building the index of '%s', only the first time...\n", fn)
+		if err := db.buildIndex(); err != nil {
+			f.Close()
+			return nil, err
+		}
+		// without the cache the index is built again the next time
+		_ = writeIndex(cache, fi, db.offsets)
+	}
+
+	return db, nil
+}
+
+// Close closes the dataset file
+func (db *breachDB) Close() error {
+	return db.f.Close()
+}
+
+// Count returns how many times the password appears in the breaches, 0 if never
+func (db *breachDB) Count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	bucket := binary.BigEndian.Uint16(sum[:2])
+	off, err := db.lowerBound(hash, db.offsets[bucket], db.offsets[bucket+1])
+	if err != nil || off >= db.size {
+		return 0, err
+	}
+
+	line, err := db.line(off)
+	if err != nil {
+		return 0, err
+	}
+	h, count, err := parseLine(line)
+	if err != nil || h != hash {
+		return 0, err
+	}
+	return count, nil
+}
+
+// checkFormat checks that the first line is a SHA-1 hash with its count
+func (db *breachDB) checkFormat() error {
+	if db.size == 0 {
+		return fmt.Errorf("invalid breached passwords file: it is empty")
+	}
+	line, err := db.line(0)
+	if err != nil {
+		return err
+	}
+	if _, _, err := parseLine(line); err != nil {
+		return fmt.Errorf("invalid breached passwords file: the SHA-1 ordered by hash version is required")
+	}
+	return nil
+}
+
+// buildIndex finds the start of each prefix bucket
+func (db *breachDB) buildIndex() error {
+	db.offsets = make([]int64, indexBuckets+1)
+	for i := 1; i < indexBuckets; i++ {
+		prefix := fmt.Sprintf("%04X", i) + strings.Repeat("0", 36)
+		off, err := db.lowerBound(prefix, db.offsets[i-1], db.size)
+		if err != nil {
+			return err
+		}
+		db.offsets[i] = off
+	}
+	db.offsets[indexBuckets] = db.size
+	return nil
+}
+
+// lowerBound returns the start of the first line in [lo, hi) whose hash is not lower than the given one,
+// lo must be the start of a line
+func (db *breachDB) lowerBound(hash string, lo, hi int64) (int64, error) {
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, err := db.nextLine(lo, mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			// no line starts in [mid, hi)
+			hi = mid
+			continue
+		}
+
+		line, err := db.line(start)
+		if err != nil {
+			return 0, err
+		}
+		h, _, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+		if h < hash {
+			lo = start + int64(len(line))
+		} else {
+			hi = start
+		}
+	}
+	return lo, nil
+}
+
+// nextLine returns the start of the first line starting at or after pos
+func (db *breachDB) nextLine(lo, pos int64) (int64, error) {
+	if pos == lo {
+		return pos, nil
+	}
+
+	buf, err := db.read(pos - 1)
+	if err != nil {
+		return 0, err
+	}
+	i := bytes.IndexByte(buf, '\n')
+	if i < 0 {
+		if pos-1+int64(len(buf)) >= db.size {
+			return db.size, nil
+		}
+		return 0, fmt.Errorf("invalid breached passwords file: line too long at offset %d", pos)
+	}
+	return pos + int64(i), nil
+}
+
+// line returns the line starting at the offset, with its line terminator
+func (db *breachDB) line(off int64) ([]byte, error) {
+	buf, err := db.read(off)
+	if err != nil {
+		return nil, err
+	}
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		return buf[:i+1], nil
+	}
+	if off+int64(len(buf)) < db.size {
+		return nil, fmt.Errorf("invalid breached passwords file: line too long at offset %d", off)
+	}
+	return buf, nil
+}
+
+// read returns up to maxLineLength bytes at the offset
+func (db *breachDB) read(off int64) ([]byte, error) {
+	buf := make([]byte, maxLineLength)
+	n, err := db.f.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// parseLine returns the upper case hash and the count of a "<hash>:<count>" line
+func parseLine(line []byte) (string, int, error) {
+	parts := strings.SplitN(strings.TrimSpace(string(line)), ":", 2)
+	if len(parts) != 2 || len(parts[0]) != 2*sha1.Size {
+		return "", 0, fmt.Errorf("invalid breached passwords file: bad line '%s'", strings.TrimSpace(string(line)))
+	}
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid breached passwords file: bad line '%s'", strings.TrimSpace(string(line)))
+	}
+	return strings.ToUpper(parts[0]), count, nil
+}
+
+// indexCachePath returns the index file of the dataset in the user cache directory,
+// the empty string when there is none
+func indexCachePath(fn string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(fn)
+	if err != nil {
+		return ""
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(dir, "pwsafe", fmt.Sprintf("hibp-%x.idx", sum[:8]))
+}
+
+// readIndex returns the cached index, nil if missing or if the dataset has changed since it was built
+func readIndex(fn string, fi os.FileInfo) []int64 {
+	if fn == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil || len(data) != indexSize || string(data[:len(indexMagic)]) != indexMagic {
+		return nil
+	}
+
+	data = data[len(indexMagic):]
+	if int64(binary.LittleEndian.Uint64(data)) != fi.Size() ||
+		int64(binary.LittleEndian.Uint64(data[8:])) != fi.ModTime().UnixNano() {
+		return nil
+	}
+
+	data = data[16:]
+	res := make([]int64, indexBuckets+1)
+	for i := range res {
+		res[i] = int64(binary.LittleEndian.Uint64(data[i*8:]))
+		if res[i] > fi.Size() || (i > 0 && res[i] < res[i-1]) {
+			return nil
+		}
+	}
+	return res
+}
+
+// writeIndex saves the index with the size and the modification time of the dataset
+func writeIndex(fn string, fi os.FileInfo, offsets []int64) error {
+	if fn == "" {
+		return fmt.Errorf("missing user cache directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+		return err
+	}
+
+	buf := make([]byte, indexSize)
+	copy(buf, indexMagic)
+	data := buf[len(indexMagic):]
+	binary.LittleEndian.PutUint64(data, uint64(fi.Size()))
+	binary.LittleEndian.PutUint64(data[8:], uint64(fi.ModTime().UnixNano()))
+	for i, off := range offsets {
+		binary.LittleEndian.PutUint64(data[16+i*8:], uint64(off))
+	}
+	return ioutil.WriteFile(fn, buf, 0600)
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeBreachFile writes the passwords with their counts as a HIBP ordered by hash file
+func writeBreachFile(t *testing.T, fn string, counts map[string]int) []string {
+	var lines []string
+	for p, count := range counts {
+		hash := fmt.Sprintf("%X", sha1.Sum([]byte(p)))
+		lines = append(lines, fmt.Sprintf("%s:%d\r\n", hash, count))
+		// a neighbour in the same bucket, so that the search is not only among buckets
+		neighbour := hash[:39] + "0"
+		if neighbour == hash {
+			neighbour = hash[:39] + "1"
+		}
+		lines = append(lines, neighbour+":1\r\n")
+	}
+	sort.Strings(lines)
+	if err := ioutil.WriteFile(fn, []byte(strings.Join(lines, "")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// withCacheDir points the user cache directory to the temporary one
+func withCacheDir(dir string) func() {
+	var restore []func()
+	for _, name := range []string{"XDG_CACHE_HOME", "HOME", "LocalAppData"} {
+		old, ok := os.LookupEnv(name)
+		os.Setenv(name, dir)
+		name := name
+		restore = append(restore, func() {
+			if ok {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+	return func() {
+		for _, f := range restore {
+			f()
+		}
+	}
+}
+
+func TestBreachDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer withCacheDir(dir)()
+
+	counts := map[string]int{"password": 3861493, "123456": 37359195, "qwerty": 10556095, "letmein": 1000}
+	fn := filepath.Join(dir, "pwned.txt")
+	lines := writeBreachFile(t, fn, counts)
+
+	db, err := openBreachDB(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for p, expected := range counts {
+		count, err := db.Count(p)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, count, p)
+	}
+
+	count, err := db.Count("not a breached password")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	// the first and the last lines of the file
+	first, _, _ := parseLine([]byte(lines[0]))
+	off, err := db.lowerBound(first, 0, db.size)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), off)
+
+	last, _, _ := parseLine([]byte(lines[len(lines)-1]))
+	off, err = db.lowerBound(last, 0, db.size)
+	assert.Nil(t, err)
+	assert.Equal(t, db.size-int64(len(lines[len(lines)-1])), off)
+
+	off, err = db.lowerBound(strings.Repeat("F", 40), 0, db.size)
+	assert.Nil(t, err)
+	assert.Equal(t, db.size, off)
+
+	// a position in the middle of the first line
+	off, err = db.nextLine(0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(lines[0])), off)
+
+	off, err = db.nextLine(0, db.size-1)
+	assert.Nil(t, err)
+	assert.Equal(t, db.size, off)
+}
+
+func TestBreachDBIndexCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer withCacheDir(dir)()
+
+	fn := filepath.Join(dir, "pwned.txt")
+	writeBreachFile(t, fn, map[string]int{"password": 10})
+
+	db, err := openBreachDB(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built := db.offsets
+	db.Close()
+
+	fi, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := indexCachePath(fn)
+	assert.Equal(t, built, readIndex(cache, fi))
+
+	// the dataset is updated, the cached index is stale
+	writeBreachFile(t, fn, map[string]int{"password": 20, "qwerty": 5})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fn, later, later); err != nil {
+		t.Fatal(err)
+	}
+	fi, err = os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, readIndex(cache, fi))
+
+	db, err = openBreachDB(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	count, err := db.Count("qwerty")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, db.offsets, readIndex(cache, fi))
+}
@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// the checks, in the order they are reported
+var checkNames = []string{"weak", "reused", "username", "title", "old", "url", "expired", "breached"}
+
+// check returns the details of the record issue, the empty string if there is none
+type check func(a *auditor, rec pwsafe.Record) string
+
+var checks = map[string]check{
+	"weak":     checkWeak,
+	"reused":   checkReused,
+	"username": checkUsername,
+	"title":    checkTitle,
+	"old":      checkOld,
+	"url":      checkURL,
+	"expired":  checkExpired,
+	"breached": checkBreached,
+}
+
+// issue is a problem found in a record
+type issue struct {
+	Title  string `json:"title"`
+	Group  string `json:"group,omitempty"`
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+	Count  int    `json:"count,omitempty"` // how many times the password appears in the breaches
+}
+
+// auditor holds the settings and what is shared by the records
+type auditor struct {
+	now       time.Time
+	minScore  int
+	maxAge    time.Duration
+	breaches  *breachDB
+	passwords map[string][]string // the titles of the records using each password
+	breached  map[string]int      // how many times each password appears in the breaches
+}
+
+// run returns the issues of the records found by the checks
+func (a *auditor) run(db pwsafe.DB, titles []string, names []string) ([]issue, error) {
+	a.passwords = make(map[string][]string)
+	for _, t := range titles {
+		rec, _ := db.GetRecord(t)
+		a.passwords[rec.Password] = append(a.passwords[rec.Password], rec.Title)
+	}
+
+	a.breached = make(map[string]int)
+	if a.breaches != nil {
+		for pw := range a.passwords {
+			count, err := a.breaches.Count(pw)
+			if err != nil {
+				return nil, err
+			}
+			a.breached[pw] = count
+		}
+	}
+
+	var res []issue
+	for _, t := range titles {
+		rec, _ := db.GetRecord(t)
+		for _, name := range names {
+			if detail := checks[name](a, rec); detail != "" {
+				el := issue{Title: rec.Title, Group: rec.Group, Check: name, Detail: detail}
+				if name == "breached" {
+					el.Count = a.breached[rec.Password]
+				}
+				res = append(res, el)
+			}
+		}
+	}
+	return res, nil
+}
+
+func checkWeak(a *auditor, rec pwsafe.Record) string {
+	s := pwsafe.EstimatePasswordStrength(rec.Password, rec.Username, rec.Title)
+	if s.Score >= a.minScore {
+		return ""
+	}
+	return fmt.Sprintf("strength %d/4, about %.0f bits", s.Score, s.Entropy)
+}
+
+func checkReused(a *auditor, rec pwsafe.Record) string {
+	var others []string
+	for _, t := range a.passwords[rec.Password] {
+		if t != rec.Title {
+			others = append(others, fmt.Sprintf("'%s'", t))
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	sort.Strings(others)
+	return fmt.Sprintf("same password of %s", strings.Join(others, ", "))
+}
+
+func checkUsername(a *auditor, rec pwsafe.Record) string {
+	if rec.Username == "" || !strings.EqualFold(strings.TrimSpace(rec.Username), rec.Password) {
+		return ""
+	}
+	return "the password is the username"
+}
+
+func checkTitle(a *auditor, rec pwsafe.Record) string {
+	if !strings.EqualFold(strings.TrimSpace(rec.Title), rec.Password) {
+		return ""
+	}
+	return "the password is the title"
+}
+
+func checkOld(a *auditor, rec pwsafe.Record) string {
+	if a.maxAge <= 0 {
+		return ""
+	}
+
+	changed := rec.LastPasswordChange()
+	if changed.IsZero() || a.now.Sub(changed) <= a.maxAge {
+		return ""
+	}
+	return fmt.Sprintf("changed %d days ago", int(a.now.Sub(changed).Hours()/24))
+}
+
+func checkURL(a *auditor, rec pwsafe.Record) string {
+	if strings.TrimSpace(rec.URL) != "" {
+		return ""
+	}
+	return "missing URL"
+}
+
+func checkExpired(a *auditor, rec pwsafe.Record) string {
+	if !rec.PasswordExpired(a.now) {
+		return ""
+	}
+	return fmt.Sprintf("expired on %s", rec.PasswordExpiry.Format("2006-01-02"))
+}
+
+func checkBreached(a *auditor, rec pwsafe.Record) string {
+	count := a.breached[rec.Password]
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("seen %d times in data breaches", count)
+}
+
+// parseChecks returns the checks to run, all if none is specified.
+// The breached check requires the breached passwords file.
+func parseChecks(val string, breaches bool) ([]string, error) {
+	if strings.TrimSpace(val) == "" {
+		var res []string
+		for _, name := range checkNames {
+			if name != "breached" || breaches {
+				res = append(res, name)
+			}
+		}
+		return res, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(val, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := checks[name]; !ok {
+			return nil, fmt.Errorf("unknown check '%s' - accepted values are: %s", name, strings.Join(checkNames, ", "))
+		}
+		if name == "breached" && !breaches {
+			return nil, fmt.Errorf("the breached check requires -breach-db")
+		}
+		selected[name] = true
+	}
+
+	var res []string
+	for _, name := range checkNames {
+		if selected[name] {
+			res = append(res, name)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no checks to run")
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package clip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+const (
+	// HelperEnv marks the process forked to clear the clipboard
+	HelperEnv = "PWSAFE_CLIP_HELPER"
+
+	timeoutEnv = "PWSAFE_CLIP_TIMEOUT"
+)
+
+// clearRequest is sent on stdin to the clipboard clearing helper,
+// so that nothing sensitive shows up in the process list
+type clearRequest struct {
+	Delay    time.Duration `json:"delay"`
+	Hash     string        `json:"hash"`
+	Previous string        `json:"previous"`
+}
+
+// scheduleClear forks a detached process that will clear the clipboard after the delay
+func scheduleClear(delay time.Duration, content, previous string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), HelperEnv+"=1")
+	cmd.SysProcAttr = detachedProcAttr()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	err = json.NewEncoder(stdin).Encode(clearRequest{
+		Delay:    delay,
+		Hash:     contentHash(content),
+		Previous: previous,
+	})
+	stdin.Close()
+	if err != nil {
+		return err
+	}
+
+	return cmd.Process.Release()
+}
+
+// RunHelper waits for the requested delay then clears the clipboard,
+// only if it still holds the copied content
+func RunHelper() error {
+	var req clearRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return err
+	}
+
+	time.Sleep(req.Delay)
+
+	current, err := clipboard.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	if contentHash(current) != req.Hash {
+		return nil
+	}
+
+	return clipboard.WriteAll(req.Previous)
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/cli"
 	"github.com/lucasepe/pwsafe"
@@ -17,6 +19,9 @@ import (
 type clipAction struct {
 	field    string
 	title    string
+	timeout  time.Duration
+	restore  bool
+	sequence bool
 	filename string
 }
 
@@ -25,9 +30,14 @@ const (
 	shortDesc = "copy the content of the specified field to the clipboard"
 	longDesc  = `Copy the content of the specified field to the clipboard.
 
-Usage: %s %s -field=user|pass|url|notes <Record Title>
+Usage: %s %s -field=user|pass|url|notes [-timeout 30s] [-restore] <Record Title>
 
  * accepted values for 'field' are: user, pass, url, notes
+ * the clipboard is cleared after the timeout, if it still holds the copied content
+ * the default timeout can be set with the PWSAFE_CLIP_TIMEOUT variable (0 disables it)
+ * with -restore the previous clipboard content is restored instead
+ * with -sequence the record autotype fields are put on the clipboard one after another
+   (when the record has no autotype the default '\u\t\p\n' is used)
 `
 )
 
@@ -84,7 +94,26 @@ func (r *clipAction) handler() error {
 
 	for _, t := range titles {
 		if strings.EqualFold(r.title, t) {
-			copyFieldContentToClipboard(r.field, t, db)
+			previous, _ := clipboard.ReadAll()
+			var content string
+			var ok bool
+			if r.sequence {
+				content, ok, err = copySequenceToClipboard(t, db)
+				if err != nil {
+					return err
+				}
+			} else {
+				content, ok, err = copyFieldContentToClipboard(r.field, t, db)
+				if err != nil {
+					return err
+				}
+			}
+			if ok && r.timeout > 0 {
+				if !r.restore {
+					previous = ""
+				}
+				return scheduleClear(r.timeout, content, previous)
+			}
 			break
 		}
 	}
@@ -96,6 +125,9 @@ func (r *clipAction) flagHandler(fn string) func(fs *flag.FlagSet) {
 	return func(fs *flag.FlagSet) {
 		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
 		fs.StringVar(&(r.field), "field", "pass", "the field to copy content - user, pass, url")
+		fs.DurationVar(&(r.timeout), "timeout", defaultTimeout(), "clear the clipboard after this delay (0 to disable)")
+		fs.BoolVar(&(r.restore), "restore", false, "restore the previous clipboard content after the timeout")
+		fs.BoolVar(&(r.sequence), "sequence", false, "copy the record autotype fields one after another")
 	}
 }
 
@@ -105,22 +137,66 @@ func (r *clipAction) flagPostParser(fs *flag.FlagSet) {
 	}
 }
 
-func copyFieldContentToClipboard(field, name string, db pwsafe.DB) {
+func copyFieldContentToClipboard(field, name string, db pwsafe.DB) (string, bool, error) {
 	rec, ok := db.GetRecord(name)
 	if !ok {
-		return
+		return "", false, nil
 	}
 
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return "", false, err
+	}
+
+	var content string
 	switch strings.ToLower(field) {
 	case "pass":
-		clipboard.WriteAll(rec.Password)
+		content = rec.Password
 	case "user":
-		clipboard.WriteAll(rec.Username)
+		content = rec.Username
 	case "notes":
-		clipboard.WriteAll(rec.Notes)
+		content = rec.Notes
 	default:
-		clipboard.WriteAll(rec.URL)
+		content = rec.URL
+	}
+
+	if err := clipboard.WriteAll(content); err != nil {
+		return "", false, err
 	}
 
 	fmt.Printf("\U0001f44d check your clipboard for the content of the field '%s'\n", field)
+	return content, true, nil
+}
+
+func copySequenceToClipboard(name string, db pwsafe.DB) (string, bool, error) {
+	rec, ok := db.GetRecord(name)
+	if !ok {
+		return "", false, nil
+	}
+
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return "", false, err
+	}
+
+	rec.Autotype, err = pwsafe.ExpandReferences(rec.Autotype, db)
+	if err != nil {
+		return "", false, err
+	}
+
+	last, err := copySequence(rec)
+	return last, last != "", err
+}
+
+// defaultTimeout returns the clipboard timeout set by PWSAFE_CLIP_TIMEOUT or 30 seconds
+func defaultTimeout() time.Duration {
+	if val := strings.TrimSpace(os.Getenv(timeoutEnv)); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+		if secs, err := strconv.Atoi(val); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return 30 * time.Second
 }
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package clip
+
+import "syscall"
+
+// detachedProcAttr starts the helper in a new session, so it survives the terminal
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
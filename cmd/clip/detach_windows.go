@@ -0,0 +1,10 @@
+package clip
+
+import "syscall"
+
+const detachedProcess = 0x00000008
+
+// detachedProcAttr starts the helper without a console, so it survives the terminal
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
@@ -0,0 +1,79 @@
+package clip
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// copySequence walks the record autotype sequence putting each text on the clipboard,
+// waiting for a keypress before moving to the next one
+func copySequence(rec pwsafe.Record) (string, error) {
+	var last string
+	steps := rec.AutotypeSteps(time.Now())
+	for i, step := range steps {
+		switch step.Action {
+		case pwsafe.AutotypeDelay:
+			time.Sleep(step.Delay)
+			continue
+		case pwsafe.AutotypeKey:
+			fmt.Printf("  ⌨️  press %s in the target window\n", step.Text)
+			continue
+		}
+
+		if err := clipboard.WriteAll(step.Text); err != nil {
+			return last, err
+		}
+		last = step.Text
+
+		fmt.Printf("\U0001f44d step %d of %d is on your clipboard", i+1, len(steps))
+		// the keys and delays after the last text need no keypress (i.e. the final Enter)
+		if !textFollows(steps, i) {
+			fmt.Println("")
+			continue
+		}
+
+		fmt.Print(" - press any key for the next one (q to quit)")
+		key, err := readKey()
+		fmt.Println("")
+		if err != nil {
+			return last, err
+		}
+		if key == 'q' || key == 'Q' || key == 0x1b || key == 0x03 {
+			break
+		}
+	}
+
+	return last, nil
+}
+
+// textFollows tells if any step after the i-th one puts a text on the clipboard
+func textFollows(steps []pwsafe.AutotypeStep, i int) bool {
+	for _, step := range steps[i+1:] {
+		if step.Action == pwsafe.AutotypeText {
+			return true
+		}
+	}
+	return false
+}
+
+// readKey waits for a single keypress
+func readKey() (byte, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return 0, err
+	}
+	defer terminal.Restore(fd, state)
+
+	buf := make([]byte, 1)
+	if _, err := os.Stdin.Read(buf); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
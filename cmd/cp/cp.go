@@ -0,0 +1,267 @@
+package cp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lucasepe/cli"
+	"github.com/pborman/uuid"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type cpAction struct {
+	args     []string
+	from     string
+	to       string
+	pattern  bool
+	newUUID  bool
+	conflict string
+}
+
+const (
+	cmdName   = "cp"
+	shortDesc = "copy records to another password store"
+	longDesc  = `Copy records (with all their fields and password history) to another password store.
+
+Usage: %s %s -to <store> [-from <store>] <Record Title>...
+       %s %s -to <store> [-from <store>] -pattern <pattern>...
+
+ * the records keep their UUID and times unless -new-uuid is specified
+ * the titles can be qualified by their group as in 'Group/Title'
+ * with -pattern all the records whose title matches one of the patterns are copied
+ * when a record with the same title already exists in the destination store
+   -conflict tells what to do: fail (default), skip, overwrite or rename
+ * the record fields of newer Password Safe versions, unknown to pwsafe, are copied as they are
+`
+)
+
+// NewCpCommand create a 'cp' cli command
+func NewCpCommand(filename string) *cli.Command {
+	action := cpAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *cpAction) handler() error {
+	if len(r.args) == 0 {
+		return utils.NewMissingParameterError("record title", cmdName)
+	}
+	if strings.TrimSpace(r.to) == "" {
+		return utils.NewMissingParameterError("to", cmdName)
+	}
+
+	switch r.conflict {
+	case "fail", "skip", "overwrite", "rename":
+	default:
+		return fmt.Errorf("invalid conflict mode '%s' - accepted values are: fail, skip, overwrite, rename", r.conflict)
+	}
+
+	var err error
+	r.from, err = utils.GetAbsolutePath(r.from)
+	if err != nil {
+		return err
+	}
+	r.to, err = utils.GetAbsolutePath(r.to)
+	if err != nil {
+		return err
+	}
+	if r.from == r.to {
+		return fmt.Errorf("source and destination stores are the same file")
+	}
+
+	src, err := openStore(r.from)
+	if err != nil {
+		return err
+	}
+
+	dst, err := openStore(r.to)
+	if err != nil {
+		return err
+	}
+
+	titles, err := r.matchingTitles(src)
+	if err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return fmt.Errorf("no records matching %s", strings.Join(r.args, ", "))
+	}
+
+	copied, err := r.copyRecords(titles, src, dst)
+	if err != nil {
+		return err
+	}
+	if copied == 0 {
+		fmt.Println("no records copied")
+		return nil
+	}
+
+	err = pwsafe.WritePWSafeFile(dst, r.to)
+	if err == nil {
+		fmt.Printf("\U0001f44d %d records successfully copied to store '%s'\n", copied, r.to)
+	}
+
+	return err
+}
+
+// copyRecords copies the records to the destination db returning how many have been copied
+func (r *cpAction) copyRecords(titles []string, src, dst pwsafe.DB) (int, error) {
+	// check the conflicts first, so that nothing is copied on failure
+	if r.conflict == "fail" {
+		for _, t := range titles {
+			if _, exists := dst.GetRecord(t); exists {
+				return 0, fmt.Errorf("record '%s' already exists in store '%s'", t, r.to)
+			}
+		}
+	}
+
+	// the old and new UUIDs, to fix the references among the copied records
+	uuids := make(map[[16]byte][16]byte)
+	var copied []string
+	for _, t := range titles {
+		rec, _ := src.GetRecord(t)
+		oldID := rec.UUID
+
+		if _, exists := dst.GetRecord(t); exists {
+			switch r.conflict {
+			case "skip":
+				fmt.Printf("%s: already exists, skipped\n", t)
+				continue
+			case "rename":
+				rec.Title = availableTitle(t, dst)
+			}
+		}
+
+		if r.newUUID {
+			rec.UUID = [16]byte(uuid.NewRandom().Array())
+		}
+		rec = dst.ImportRecord(rec)
+		uuids[oldID] = rec.UUID
+		copied = append(copied, rec.Title)
+
+		if rec.Title != t {
+			fmt.Printf("%s -> %s\n", t, rec.Title)
+		} else {
+			fmt.Println(t)
+		}
+	}
+
+	for _, t := range copied {
+		rec, _ := dst.GetRecord(t)
+		kind, baseID := rec.EntryType()
+		if kind == pwsafe.NormalEntry {
+			continue
+		}
+
+		if newID, ok := uuids[baseID]; ok && newID != baseID {
+			base := pwsafe.Record{UUID: newID}
+			if kind == pwsafe.AliasEntry {
+				rec.Password = pwsafe.AliasReference(base)
+			} else {
+				rec.Password = pwsafe.ShortcutReference(base)
+			}
+			dst.ImportRecord(rec)
+		}
+
+		if _, err := dst.ResolveRecord(rec); err != nil {
+			fmt.Printf("warning: %s\n", err.Error())
+		}
+	}
+
+	return len(copied), nil
+}
+
+// matchingTitles returns the titles selected by the command arguments
+func (r *cpAction) matchingTitles(db pwsafe.DB) ([]string, error) {
+	if !r.pattern {
+		titles := make([]string, 0, len(r.args))
+		for _, el := range r.args {
+			rec, ok := utils.FindRecord(el, db)
+			if !ok {
+				return nil, fmt.Errorf("record '%s' not found", el)
+			}
+			titles = append(titles, rec.Title)
+		}
+		return titles, nil
+	}
+
+	exps := make([]*regexp.Regexp, 0, len(r.args))
+	for _, el := range r.args {
+		exp, err := regexp.Compile(fmt.Sprintf("(?i)%s", el))
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+
+	var titles []string
+	for _, t := range db.List() {
+		for _, exp := range exps {
+			if exp.MatchString(t) {
+				titles = append(titles, t)
+				break
+			}
+		}
+	}
+	return titles, nil
+}
+
+// availableTitle returns the title with a ' (copy)' suffix not used in the db
+func availableTitle(title string, db pwsafe.DB) string {
+	res := fmt.Sprintf("%s (copy)", title)
+	for i := 2; ; i++ {
+		if _, exists := db.GetRecord(res); !exists {
+			return res
+		}
+		res = fmt.Sprintf("%s (copy %d)", title, i)
+	}
+}
+
+// openStore opens the store asking for its secret phrase if there is no key for it
+func openStore(filename string) (pwsafe.DB, error) {
+	_, err := utils.FileExist(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(filename)
+	if err != nil {
+		fmt.Printf("Store '%s'\n", filename)
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pwsafe.OpenPWSafeFile(filename, secret)
+}
+
+func (r *cpAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.from), "from", fn, "source password store file")
+		fs.StringVar(&(r.to), "to", "", "destination password store file")
+		fs.BoolVar(&(r.pattern), "pattern", false, "match the record titles using the arguments as regular expressions")
+		fs.BoolVar(&(r.newUUID), "new-uuid", false, "assign new UUIDs to the copied records")
+		fs.StringVar(&(r.conflict), "conflict", "fail", "what to do when the title already exists: fail, skip, overwrite, rename")
+	}
+}
+
+func (r *cpAction) flagPostParser(fs *flag.FlagSet) {
+	r.args = fs.Args()
+}
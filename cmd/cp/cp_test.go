@@ -0,0 +1,51 @@
+package cp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestCopyUnknownFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a record holding a field of a newer Password Safe version
+	unknown := []pwsafe.RawField{{Type: 0x40, Data: []byte("from the future")}}
+	src := pwsafe.NewV3("", "password")
+	src.SetRecord(pwsafe.Record{Title: "future", Password: "s3cr3t", UnknownFields: unknown})
+	srcFile := filepath.Join(dir, "src.dat")
+	if err := pwsafe.WritePWSafeFile(src, srcFile); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := pwsafe.OpenPWSafeFile(srcFile, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := pwsafe.NewV3("", "password")
+	r := cpAction{conflict: "fail", to: filepath.Join(dir, "dst.dat")}
+	copied, err := r.copyRecords([]string{"future"}, read, dst)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, copied)
+	if err := pwsafe.WritePWSafeFile(dst, r.to); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := pwsafe.OpenPWSafeFile(r.to, "password")
+	if assert.Nil(t, err) {
+		rec, ok := res.GetRecord("future")
+		assert.True(t, ok)
+		assert.Equal(t, "s3cr3t", rec.Password)
+		assert.Equal(t, unknown, rec.UnknownFields)
+	}
+}
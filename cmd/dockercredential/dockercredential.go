@@ -0,0 +1,264 @@
+package dockercredential
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+// BinaryName is the name docker looks for when 'credsStore' is set to 'pwsafe'
+const BinaryName = "docker-credential-pwsafe"
+
+type dockerCredentialAction struct {
+	operation string
+	group     string
+	filename  string
+}
+
+// credentials is the payload exchanged using the docker credential helper protocol
+type credentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// notFoundMessage is the error docker expects when a server has no credentials
+const notFoundMessage = "credentials not found in native keychain"
+
+const (
+	cmdName   = "docker-credential"
+	shortDesc = "act as a docker credential helper"
+	longDesc  = `Act as a docker credential helper (get, store, erase and list operations).
+
+Usage: %s %s [options] get|store|erase|list
+
+To let docker use it, create a link named '%s' somewhere in your PATH:
+
+  ln -s $(which %s) /usr/local/bin/%s
+
+and set '"credsStore": "pwsafe"' in your '~/.docker/config.json'.
+
+ * server URLs are mapped to the URL field of the records
+ * credentials are stored in the group specified by -group
+ * storing the credentials of an alias updates the password of its base entry
+ * the secret phrase is read from the terminal, since stdin is used by docker
+`
+)
+
+// NewDockerCredentialCommand create a 'docker-credential' cli command
+func NewDockerCredentialCommand(filename string) *cli.Command {
+	action := dockerCredentialAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, BinaryName, bin, BinaryName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+// Run executes the helper when the binary is invoked as 'docker-credential-pwsafe <operation>'
+func Run(filename string, args []string) error {
+	action := dockerCredentialAction{}
+
+	fs := flag.NewFlagSet(BinaryName, flag.ContinueOnError)
+	action.flagHandler(filename)(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	action.flagPostParser(fs)
+
+	return action.handler()
+}
+
+func (r *dockerCredentialAction) handler() error {
+	switch r.operation {
+	case "get", "store", "erase", "list":
+	case "":
+		return utils.NewMissingParameterError("operation", cmdName)
+	default:
+		return fmt.Errorf("unknown operation '%s'", r.operation)
+	}
+
+	var in []byte
+	if r.operation != "list" {
+		var err error
+		in, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhraseFromTTY()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	switch r.operation {
+	case "get":
+		return r.get(strings.TrimSpace(string(in)), db, os.Stdout)
+	case "store":
+		var creds credentials
+		if err := json.Unmarshal(in, &creds); err != nil {
+			return err
+		}
+		return r.store(creds, db)
+	case "erase":
+		return r.erase(strings.TrimSpace(string(in)), db)
+	default:
+		return r.list(db, os.Stdout)
+	}
+}
+
+func (r *dockerCredentialAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.group), "group", "Docker", "the group where the docker credentials are stored")
+	}
+}
+
+func (r *dockerCredentialAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.operation = fs.Args()[0]
+	}
+}
+
+func (r *dockerCredentialAction) get(serverURL string, db pwsafe.DB, out io.Writer) error {
+	rec, ok := r.findRecord(serverURL, db)
+	if !ok {
+		return errors.New(notFoundMessage)
+	}
+
+	// an alias holds the reference to the password of its base entry
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(out).Encode(credentials{
+		ServerURL: serverURL,
+		Username:  rec.Username,
+		Secret:    rec.Password,
+	})
+}
+
+func (r *dockerCredentialAction) store(creds credentials, db pwsafe.DB) error {
+	if strings.TrimSpace(creds.ServerURL) == "" {
+		return fmt.Errorf("missing server URL")
+	}
+	// the records without a password are dropped when the store is saved
+	if creds.Secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	rec, ok := r.findRecord(creds.ServerURL, db)
+	if !ok {
+		rec.Title = serverKey(creds.ServerURL)
+		if _, exists := db.GetRecord(rec.Title); exists {
+			return fmt.Errorf("a record titled '%s' already exists", rec.Title)
+		}
+		rec.Group = r.group
+		rec.URL = creds.ServerURL
+	}
+	rec.Username = creds.Username
+
+	// an alias or a shortcut shares the password of its base entry, that is the one updated
+	if kind, baseID := rec.EntryType(); kind != pwsafe.NormalEntry {
+		if _, err := db.ResolveRecord(rec); err != nil {
+			return err
+		}
+		base, _ := db.GetRecordByUUID(baseID)
+		base.Password = creds.Secret
+		db.SetRecord(base)
+	} else {
+		rec.Password = creds.Secret
+	}
+
+	db.SetRecord(rec)
+	if !db.NeedsSave() {
+		return nil
+	}
+
+	return pwsafe.WritePWSafeFile(db, r.filename)
+}
+
+func (r *dockerCredentialAction) erase(serverURL string, db pwsafe.DB) error {
+	rec, ok := r.findRecord(serverURL, db)
+	if !ok {
+		return errors.New(notFoundMessage)
+	}
+
+	db.DeleteRecord(rec.Title)
+	return pwsafe.WritePWSafeFile(db, r.filename)
+}
+
+func (r *dockerCredentialAction) list(db pwsafe.DB, out io.Writer) error {
+	res := make(map[string]string)
+	for _, t := range db.ListByGroup(r.group) {
+		if rec, ok := db.GetRecord(t); ok && rec.URL != "" {
+			res[rec.URL] = rec.Username
+		}
+	}
+
+	return json.NewEncoder(out).Encode(res)
+}
+
+// findRecord returns the record of the helper group with the URL matching the server
+func (r *dockerCredentialAction) findRecord(serverURL string, db pwsafe.DB) (pwsafe.Record, bool) {
+	key := serverKey(serverURL)
+	for _, t := range db.ListByGroup(r.group) {
+		if rec, ok := db.GetRecord(t); ok && serverKey(rec.URL) == key {
+			return rec, true
+		}
+	}
+
+	return pwsafe.Record{}, false
+}
+
+// serverKey normalizes a server URL so that 'https://registry.io/' and 'registry.io' are the same
+func serverKey(serverURL string) string {
+	u, err := utils.ParseLooseURL(serverURL)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(serverURL))
+	}
+
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		return fmt.Sprintf("%s/%s", u.Host, p)
+	}
+	return u.Host
+}
@@ -0,0 +1,125 @@
+package dockercredential
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// newTestStore returns a store with a registry record and an alias of a record of another group
+func newTestStore(t *testing.T) (pwsafe.DB, *dockerCredentialAction, func()) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pwsafe.NewV3("", "password")
+	db.SetRecord(pwsafe.Record{Title: "registry.io", Group: "Docker", URL: "https://registry.io", Username: "john", Password: "s3cr3t"})
+	db.SetRecord(pwsafe.Record{Title: "ghcr", Group: "Dev", Username: "jack", Password: "t0k3n"})
+	base, _ := db.GetRecord("ghcr")
+	db.SetRecord(pwsafe.Record{Title: "ghcr.io", Group: "Docker", URL: "ghcr.io", Username: "jack", Password: pwsafe.AliasReference(base)})
+
+	r := &dockerCredentialAction{group: "Docker", filename: filepath.Join(dir, "test.dat")}
+	return db, r, func() { os.RemoveAll(dir) }
+}
+
+func TestServerKey(t *testing.T) {
+	assert.Equal(t, "registry.io", serverKey("https://registry.io/"))
+	assert.Equal(t, "registry.io", serverKey("registry.io"))
+	assert.Equal(t, "registry.io/v2", serverKey("https://registry.io/v2/"))
+}
+
+func TestGet(t *testing.T) {
+	db, r, cleanup := newTestStore(t)
+	defer cleanup()
+
+	get := func(serverURL string) (credentials, error) {
+		var out bytes.Buffer
+		var res credentials
+		if err := r.get(serverURL, db, &out); err != nil {
+			return res, err
+		}
+		err := json.Unmarshal(out.Bytes(), &res)
+		return res, err
+	}
+
+	creds, err := get("registry.io")
+	assert.Nil(t, err)
+	assert.Equal(t, credentials{ServerURL: "registry.io", Username: "john", Secret: "s3cr3t"}, creds)
+
+	// the alias answers with the password of its base entry
+	creds, err = get("https://ghcr.io")
+	assert.Nil(t, err)
+	assert.Equal(t, credentials{ServerURL: "https://ghcr.io", Username: "jack", Secret: "t0k3n"}, creds)
+
+	_, err = get("missing.io")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, notFoundMessage, err.Error())
+	}
+}
+
+func TestStore(t *testing.T) {
+	db, r, cleanup := newTestStore(t)
+	defer cleanup()
+
+	assert.NotNil(t, r.store(credentials{ServerURL: "new.io", Username: "jim"}, db))
+	assert.NotNil(t, r.store(credentials{Username: "jim", Secret: "x"}, db))
+
+	assert.Nil(t, r.store(credentials{ServerURL: "https://new.io", Username: "jim", Secret: "n3w"}, db))
+	rec, ok := db.GetRecord("new.io")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Docker", rec.Group)
+		assert.Equal(t, "https://new.io", rec.URL)
+		assert.Equal(t, "jim", rec.Username)
+		assert.Equal(t, "n3w", rec.Password)
+	}
+
+	// a new password for the alias goes to the base entry, the alias keeps its reference
+	assert.Nil(t, r.store(credentials{ServerURL: "ghcr.io", Username: "jack", Secret: "changed"}, db))
+	alias, _ := db.GetRecord("ghcr.io")
+	kind, _ := alias.EntryType()
+	assert.Equal(t, pwsafe.AliasEntry, kind)
+	base, _ := db.GetRecord("ghcr")
+	assert.Equal(t, "changed", base.Password)
+
+	saved, err := pwsafe.OpenPWSafeFile(r.filename, "password")
+	if assert.Nil(t, err) {
+		rec, _ := saved.GetRecord("ghcr.io")
+		rec, err = saved.ResolveRecord(rec)
+		assert.Nil(t, err)
+		assert.Equal(t, "changed", rec.Password)
+	}
+}
+
+func TestErase(t *testing.T) {
+	db, r, cleanup := newTestStore(t)
+	defer cleanup()
+
+	err := r.erase("missing.io", db)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, notFoundMessage, err.Error())
+	}
+
+	assert.Nil(t, r.erase("https://registry.io/", db))
+	_, ok := db.GetRecord("registry.io")
+	assert.False(t, ok)
+}
+
+func TestList(t *testing.T) {
+	db, r, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	assert.Nil(t, r.list(db, &out))
+
+	var res map[string]string
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &res))
+	assert.Equal(t, map[string]string{"https://registry.io": "john", "ghcr.io": "jack"}, res)
+}
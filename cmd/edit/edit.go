@@ -0,0 +1,286 @@
+package edit
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/cli"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type editAction struct {
+	title    string
+	filename string
+}
+
+// document holds the editable fields of a record
+type document struct {
+	Title      string `yaml:"title"`
+	Group      string `yaml:"group"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
+	URL        string `yaml:"url"`
+	Email      string `yaml:"email"`
+	Notes      string `yaml:"notes"`
+	Autotype   string `yaml:"autotype"`
+	RunCommand string `yaml:"run_command"`
+}
+
+const (
+	cmdName   = "edit"
+	shortDesc = "edit a record with your text editor"
+	longDesc  = `Edit the record with the specified title using $VISUAL or $EDITOR.
+
+Usage: %s %s <Record Title>
+
+ * the record is written as a YAML document to a private temporary file
+   which is wiped as soon as the editor is closed
+ * the temporary file is in /dev/shm (in memory) when available; elsewhere, as on
+   macOS and Windows, it is in the system temporary directory and may hit the disk
+ * the swap and backup files of the editor are not wiped: disable them
+   (i.e. EDITOR="vim -n" for no swap file and no backup in the vim settings)
+ * if the record does not exist a new one is created
+ * the title can be qualified by its group as in 'Group/Title'
+ * changing the title renames the record
+`
+
+	documentHeader = `# Edit the record, then save and close the editor to store it.
+# Empty the title (or the whole document) to abort.
+`
+)
+
+// NewEditCommand create a 'edit' cli command
+func NewEditCommand(filename string) *cli.Command {
+	action := editAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *editAction) handler() error {
+	if strings.TrimSpace(r.title) == "" {
+		return utils.NewMissingParameterError("title", cmdName)
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	rec, exists := utils.FindRecord(r.title, db)
+	if !exists {
+		rec = pwsafe.Record{Title: strings.TrimSpace(r.title)}
+	}
+
+	orig := newDocument(rec)
+	content, err := marshalDocument(orig)
+	if err != nil {
+		return err
+	}
+
+	var doc document
+	for {
+		content, err = editContent(content)
+		if err != nil {
+			return err
+		}
+
+		doc, err = parseDocument(content)
+		if err == nil {
+			err = validateDocument(doc, rec, exists, db)
+		}
+		if err == nil {
+			break
+		}
+
+		if !askRetry(err) {
+			return fmt.Errorf("record not saved")
+		}
+		content = withError(content, err)
+	}
+
+	if doc.Title == "" {
+		fmt.Println("empty title, record not saved")
+		return nil
+	}
+	if exists && doc == orig {
+		fmt.Println("no changes")
+		return nil
+	}
+
+	if exists && doc.Title != rec.Title {
+		if err := db.RenameRecord(rec.Title, doc.Title); err != nil {
+			return err
+		}
+		rec, _ = db.GetRecord(doc.Title)
+	}
+	doc.apply(&rec)
+	db.SetRecord(rec)
+
+	err = pwsafe.WritePWSafeFile(db, r.filename)
+	if err == nil {
+		fmt.Printf("\U0001f44d record successfully saved to store '%s'\n", r.filename)
+	}
+
+	return err
+}
+
+func (r *editAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+	}
+}
+
+func (r *editAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.title = fs.Args()[0]
+	}
+}
+
+func newDocument(rec pwsafe.Record) document {
+	return document{
+		Title:      rec.Title,
+		Group:      rec.Group,
+		Username:   rec.Username,
+		Password:   rec.Password,
+		URL:        rec.URL,
+		Email:      rec.Email,
+		Notes:      rec.Notes,
+		Autotype:   rec.Autotype,
+		RunCommand: rec.RunCommand,
+	}
+}
+
+// apply copies the document fields into the record, the other fields are left untouched
+func (doc document) apply(rec *pwsafe.Record) {
+	rec.Title = doc.Title
+	rec.Group = doc.Group
+	rec.Username = doc.Username
+	rec.Password = doc.Password
+	rec.URL = doc.URL
+	rec.Email = doc.Email
+	rec.Notes = doc.Notes
+	rec.Autotype = doc.Autotype
+	rec.RunCommand = doc.RunCommand
+}
+
+func marshalDocument(doc document) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(documentHeader)
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseDocument decodes the edited content, unknown keys are reported as errors
+func parseDocument(content []byte) (document, error) {
+	var doc document
+	if len(bytes.TrimSpace(stripComments(content))) == 0 {
+		return doc, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return doc, err
+	}
+
+	doc.Title = strings.TrimSpace(doc.Title)
+	doc.Group = strings.TrimSpace(doc.Group)
+	doc.Username = strings.TrimSpace(doc.Username)
+	doc.URL = strings.TrimSpace(doc.URL)
+	doc.Email = strings.TrimSpace(doc.Email)
+	return doc, nil
+}
+
+func validateDocument(doc document, rec pwsafe.Record, exists bool, db pwsafe.DB) error {
+	if doc.Title == "" {
+		return nil
+	}
+	if doc.Password == "" {
+		return fmt.Errorf("the password can not be empty")
+	}
+
+	if !exists || doc.Title != rec.Title {
+		if _, taken := db.GetRecord(doc.Title); taken {
+			return fmt.Errorf("record '%s' already exists", doc.Title)
+		}
+	}
+
+	return nil
+}
+
+// askRetry asks if the document must be edited again
+func askRetry(err error) bool {
+	fmt.Printf("invalid record: %s\n", err.Error())
+	fmt.Print("Edit again? [Y/n] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// withError returns the content prefixed with the error comment, replacing the previous one
+func withError(content []byte, err error) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for len(lines) > 0 && bytes.HasPrefix(lines[0], []byte("# ERROR: ")) {
+		lines = lines[1:]
+	}
+
+	msg := strings.Replace(err.Error(), "\n", " ", -1)
+	return append([]byte(fmt.Sprintf("# ERROR: %s\n", msg)), bytes.Join(lines, []byte("\n"))...)
+}
+
+func stripComments(content []byte) []byte {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
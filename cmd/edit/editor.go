@@ -0,0 +1,76 @@
+package edit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	sysexec "os/exec"
+	"runtime"
+	"strings"
+)
+
+// editContent lets the user edit the content in a private temporary file, which is wiped afterwards
+func editContent(content []byte) ([]byte, error) {
+	f, err := ioutil.TempFile(tempDir(), "pwsafe-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	fn := f.Name()
+	defer wipeFile(fn)
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	args := append(editorCommand(), fn)
+	cmd := sysexec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor '%s' failed: %s", args[0], err.Error())
+	}
+
+	return ioutil.ReadFile(fn)
+}
+
+// editorCommand returns the command line of the user editor
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(name)); len(args) > 0 {
+			return args
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// tempDir prefers a memory backed file system, so that the secrets never hit the disk,
+// falling back to the system temporary directory where there is none (i.e. macOS and Windows)
+func tempDir() string {
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// wipeFile overwrites the file content with zeros before removing it
+func wipeFile(fn string) {
+	if fi, err := os.Stat(fn); err == nil {
+		if f, err := os.OpenFile(fn, os.O_WRONLY, 0600); err == nil {
+			f.Write(make([]byte, fi.Size()))
+			f.Sync()
+			f.Close()
+		}
+	}
+	os.Remove(fn)
+}
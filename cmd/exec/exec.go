@@ -0,0 +1,191 @@
+package exec
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	sysexec "os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type execAction struct {
+	env      envFlag
+	args     []string
+	mask     bool
+	inherit  bool
+	filename string
+}
+
+// envFlag collects the repeated -env NAME=Title:field options
+type envFlag []string
+
+func (e *envFlag) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlag) Set(value string) error {
+	if strings.Index(value, "=") <= 0 {
+		return fmt.Errorf("invalid env value '%s' - expected NAME=Title:field", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+const (
+	cmdName   = "exec"
+	shortDesc = "run a command with secrets injected as environment variables"
+	longDesc  = `Run a command with some record fields injected as environment variables.
+
+Usage: %s %s -env NAME=<Record Title>:<field> [-env ...] [-inherit-env] -- <command> [args...]
+
+ * accepted values for 'field' are: user, pass, url, notes, email, group, title
+ * if the field is omitted the password is injected
+ * the title can be qualified by its group as in 'Group/Title', it can hold ':'
+   since the text after the last ':' is the field only if it is a field name
+ * the command gets only the injected variables, with -inherit-env the current
+   environment is passed too
+ * [[Title:field]] references found in the notes are expanded, the other fields
+   (i.e. the passwords) are injected as they are
+`
+)
+
+// NewExecCommand create a 'exec' cli command
+func NewExecCommand(filename string) *cli.Command {
+	action := execAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *execAction) handler() error {
+	if len(r.args) == 0 {
+		return utils.NewMissingParameterError("command", cmdName)
+	}
+
+	if len(r.env) == 0 {
+		return utils.NewMissingParameterError("env", cmdName)
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	var env, secrets []string
+	if r.inherit {
+		env = os.Environ()
+	}
+
+	for _, el := range r.env {
+		idx := strings.Index(el, "=")
+		name, ref := el[:idx], el[idx+1:]
+		title, field := utils.SplitSecretRef(ref)
+		val, err := utils.ResolveField(title, field, db)
+		if err != nil {
+			return err
+		}
+		// the secrets are injected as they are, only the templates are expanded
+		if pwsafe.IsTemplateField(field) {
+			if val, err = pwsafe.ExpandReferences(val, db); err != nil {
+				return err
+			}
+		}
+		env = append(env, fmt.Sprintf("%s=%s", name, val))
+		secrets = append(secrets, val)
+	}
+
+	return run(r.args, env, secrets, r.mask)
+}
+
+func (r *execAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.Var(&(r.env), "env", "the variable to inject as NAME=Title:field (repeatable)")
+		fs.BoolVar(&(r.mask), "mask", false, "mask the secret values in the command output")
+		fs.BoolVar(&(r.inherit), "inherit-env", false, "pass the current environment to the command too")
+	}
+}
+
+func (r *execAction) flagPostParser(fs *flag.FlagSet) {
+	r.args = fs.Args()
+}
+
+// run spawns the command, forwarding signals and exiting with the child exit code
+func run(args, env, secrets []string, mask bool) error {
+	cmd := sysexec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+
+	var stdout, stderr *maskWriter
+	if mask {
+		stdout = newMaskWriter(os.Stdout, secrets)
+		stderr = newMaskWriter(os.Stderr, secrets)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	} else {
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for s := range sig {
+			cmd.Process.Signal(s)
+		}
+	}()
+
+	err := cmd.Wait()
+	signal.Stop(sig)
+	close(sig)
+
+	if mask {
+		stdout.Flush()
+		stderr.Flush()
+	}
+
+	if exitErr, ok := err.(*sysexec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			os.Exit(status.ExitStatus())
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+const maskText = "*****"
+
+// maskWriter replaces the secret values with a placeholder before writing to the underlying writer.
+// Since a secret may span several writes, the trailing bytes that could be the beginning
+// of a secret are kept until the next write or Flush.
+type maskWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	secrets [][]byte
+	maxLen  int
+	buf     []byte
+}
+
+func newMaskWriter(out io.Writer, secrets []string) *maskWriter {
+	w := &maskWriter{out: out}
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		w.secrets = append(w.secrets, []byte(s))
+		if len(s) > w.maxLen {
+			w.maxLen = len(s)
+		}
+	}
+
+	// replace the longest secrets first, so that a secret containing another one is fully masked
+	sort.Slice(w.secrets, func(i, j int) bool {
+		return len(w.secrets[i]) > len(w.secrets[j])
+	})
+
+	return w
+}
+
+func (w *maskWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for _, s := range w.secrets {
+		w.buf = bytes.Replace(w.buf, s, []byte(maskText), -1)
+	}
+
+	// keep back only what could be the beginning of a secret
+	keep := w.maxLen - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if keep > len(w.buf) {
+		keep = len(w.buf)
+	}
+	for keep > 0 && !w.isSecretPrefix(w.buf[len(w.buf)-keep:]) {
+		keep--
+	}
+
+	n := len(w.buf) - keep
+	if _, err := w.out.Write(w.buf[:n]); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+
+	return len(p), nil
+}
+
+// Flush writes out any pending byte
+func (w *maskWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.out.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// isSecretPrefix returns true if the tail of the buffer starting at b is the prefix of a secret
+func (w *maskWriter) isSecretPrefix(b []byte) bool {
+	for _, s := range w.secrets {
+		if len(b) < len(s) && bytes.HasPrefix(s, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package expiring
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/lucasepe/cli"
+	"github.com/lucasepe/tablewriter"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type expiringAction struct {
+	within   string
+	filename string
+}
+
+const (
+	cmdName   = "expiring"
+	shortDesc = "list the records whose password is due for rotation"
+	longDesc  = `List the records whose password expires soon or has already expired.
+
+Usage: %s %s [-within 14d]
+
+ * -within tells how soon (i.e. 14d, 2w, 1y), the expired passwords are always listed
+ * the records are sorted by expiry, the earliest first
+ * the password expiry is set with '%s push -expires 90d', it is moved forward
+   each time the password changes
+`
+)
+
+// NewExpiringCommand create a 'expiring' cli command
+func NewExpiringCommand(filename string) *cli.Command {
+	action := expiringAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin),
+		FlagInit:         action.flagHandler(filename),
+	}
+
+	return cmd
+}
+
+func (r *expiringAction) handler() error {
+	within, err := utils.ParseDays(r.within)
+	if err != nil {
+		return err
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	records := expiringRecords(db, now.Add(within))
+	if len(records) == 0 {
+		fmt.Printf("no passwords expiring within %s\n", r.within)
+		return nil
+	}
+
+	fmt.Println(dump(records, now))
+	return nil
+}
+
+func (r *expiringAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.within), "within", "14d", "list the passwords expiring within this time (i.e. 14d, 2w)")
+	}
+}
+
+// expiringRecords returns the records whose password expires before the deadline, the earliest first
+func expiringRecords(db pwsafe.DB, deadline time.Time) []pwsafe.Record {
+	var res []pwsafe.Record
+	for _, t := range db.List() {
+		rec, ok := db.GetRecord(t)
+		if ok && rec.PasswordExpired(deadline) {
+			res = append(res, rec)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].PasswordExpiry.Before(res[j].PasswordExpiry)
+	})
+	return res
+}
+
+// dump renders the records with their expiry
+func dump(records []pwsafe.Record, now time.Time) string {
+	table := tablewriter.CreateTable()
+	table.Style = tablewriter.GhostStyle
+	table.AddHeaders("TITLE", "CATEGORY", "USERNAME", "EXPIRY", "STATUS")
+
+	for _, rec := range records {
+		table.AddRow(
+			rec.Title,
+			rec.Group,
+			utils.TruncateText(rec.Username, 41),
+			rec.PasswordExpiry.Format("2006-01-02"),
+			status(rec, now),
+		)
+	}
+
+	return table.Render()
+}
+
+// status tells how many days ago the password has expired or in how many it expires,
+// counting the calendar days so that the one expired a few hours ago is expired today
+func status(rec pwsafe.Record, now time.Time) string {
+	days := calendarDays(now, rec.PasswordExpiry)
+	switch {
+	case rec.PasswordExpired(now) && days == 0:
+		return "expired today"
+	case rec.PasswordExpired(now):
+		return fmt.Sprintf("expired %s ago", dayCount(-days))
+	case days == 0:
+		return "expires today"
+	default:
+		return fmt.Sprintf("expires in %s", dayCount(days))
+	}
+}
+
+// calendarDays returns the number of days from the date of 'from' to the date of 'to'
+func calendarDays(from, to time.Time) int {
+	date := func(t time.Time) time.Time {
+		y, m, d := t.In(from.Location()).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return int(date(to).Sub(date(from)).Hours() / 24)
+}
+
+func dayCount(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
@@ -0,0 +1,30 @@
+package expiring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2020, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expiry time.Time
+		want   string
+	}{
+		{time.Date(2020, 3, 10, 8, 0, 0, 0, time.UTC), "expired today"},
+		{time.Date(2020, 3, 9, 23, 0, 0, 0, time.UTC), "expired 1 day ago"},
+		{time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC), "expired 9 days ago"},
+		{time.Date(2020, 3, 10, 20, 0, 0, 0, time.UTC), "expires today"},
+		{time.Date(2020, 3, 11, 1, 0, 0, 0, time.UTC), "expires in 1 day"},
+		{time.Date(2020, 3, 24, 9, 0, 0, 0, time.UTC), "expires in 14 days"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, status(pwsafe.Record{PasswordExpiry: tt.expiry}, now), tt.expiry.String())
+	}
+}
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// writeCSV writes the records with a header line holding the names of the selected fields
+func writeCSV(w io.Writer, db pwsafe.DB, opts options) error {
+	wr := csv.NewWriter(w)
+	if err := wr.Write(opts.fields); err != nil {
+		return err
+	}
+
+	row := make([]string, len(opts.fields))
+	for _, t := range opts.titles {
+		rec, _ := db.GetRecord(t)
+		// the aliases and shortcuts get the data of their base entry
+		rec, err := db.ResolveRecord(rec)
+		if err != nil {
+			return err
+		}
+		for i, f := range opts.fields {
+			row[i] = fieldValue(rec, f)
+		}
+		if err := wr.Write(row); err != nil {
+			return err
+		}
+	}
+
+	wr.Flush()
+	return wr.Error()
+}
@@ -0,0 +1,311 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lucasepe/cli"
+	"github.com/pborman/uuid"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type exportAction struct {
+	format   string
+	output   string
+	group    string
+	pattern  string
+	fields   string
+	secrets  bool
+	filename string
+}
+
+// options are the records and the fields to export
+type options struct {
+	titles []string
+	fields []string
+}
+
+// writer writes the selected records in a specific format
+type writer func(w io.Writer, db pwsafe.DB, opts options) error
+
+var writers = map[string]writer{
+	"csv":    writeCSV,
+	"json":   writeJSON,
+	"pwsxml": writePWSXML,
+}
+
+// dirWriter writes the selected records as files in the directory
+type dirWriter func(dir string, db pwsafe.DB, opts options) error
+
+var dirWriters = map[string]dirWriter{
+	"pass": writePass,
+}
+
+// fullFormats are the formats that always export the whole store
+var fullFormats = map[string]bool{
+	"pwsxml": true,
+}
+
+// secretFormats are the formats that always hold the secrets
+var secretFormats = map[string]bool{
+	"pass":   true,
+	"pwsxml": true,
+}
+
+// exportFields are the fields that can be exported, the secret ones require -include-secrets
+var exportFields = []string{"uuid", "title", "group", "user", "pass", "url", "email", "notes", "autotype", "runcmd", "created", "modified"}
+
+var (
+	defaultFields = []string{"title", "group", "user", "pass", "url", "email", "notes"}
+	secretFields  = map[string]bool{"pass": true}
+)
+
+const (
+	cmdName   = "export"
+	shortDesc = "export the store to other formats"
+	longDesc  = `Export the store to other formats.
+
+Usage: %s %s -format json|csv [-group <group>] [-pattern <regexp>] [-fields title,user,...] [-include-secrets] [-o <output file>]
+       %s %s -format pwsxml [-o <output file>]
+       %s %s -format pass [-group <group>] [-pattern <regexp>] -o <directory>
+
+ * accepted values for 'format' are: %s
+ * with -group only the records of the group (and its sub groups) are exported
+ * with -pattern only the records whose title matches the regular expression are exported
+ * -fields tells the fields to export and their order, accepted values are:
+   %s
+ * the passwords are exported only with -include-secrets, after a confirmation
+ * with json, csv and pass the aliases and shortcuts are exported with the data
+   of their base entry
+ * pwsxml is the XML format of the Password Safe desktop client, it always holds
+   the whole store: all the records, history, policies and empty groups. The header
+   fields the schema has no element for (name, description, preferences...) are not exported
+ * pass writes a password-store directory of plaintext entries (the directories are
+   the groups, the first line is the password followed by 'user:', 'url:', 'email:'
+   lines, the otpauth URI and, after an empty line, the notes) ready to be encrypted with gpg
+ * the output file is readable only by its owner, without -o the export is written
+   to the standard output
+`
+)
+
+// NewExportCommand create a 'export' cli command
+func NewExportCommand(filename string) *cli.Command {
+	action := exportAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation: fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName, bin, cmdName,
+			strings.Join(formats(), ", "), strings.Join(exportFields, ", ")),
+		FlagInit: action.flagHandler(filename),
+	}
+
+	return cmd
+}
+
+func (r *exportAction) handler() error {
+	write, ok := writers[r.format]
+	writeDir, toDir := dirWriters[r.format]
+	if !ok && !toDir {
+		return fmt.Errorf("unknown format '%s' - accepted values are: %s", r.format, strings.Join(formats(), ", "))
+	}
+
+	if fullFormats[r.format] && (r.group != "" || r.pattern != "" || r.fields != "") {
+		return fmt.Errorf("-group, -pattern and -fields can't be used with the %s format", r.format)
+	}
+	if toDir && r.fields != "" {
+		return fmt.Errorf("-fields can't be used with the %s format", r.format)
+	}
+	if toDir && strings.TrimSpace(r.output) == "" {
+		return utils.NewMissingParameterError("o", cmdName)
+	}
+
+	fields, err := r.selectedFields()
+	if err != nil {
+		return err
+	}
+
+	var exp *regexp.Regexp
+	if r.pattern != "" {
+		if exp, err = regexp.Compile(fmt.Sprintf("(?i)%s", r.pattern)); err != nil {
+			return fmt.Errorf("invalid pattern '%s' - %s", r.pattern, err.Error())
+		}
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	// the prompt goes to the terminal, the export may be written to the standard output
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhraseFromTTY()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	titles := r.matchingTitles(db, exp)
+	if len(titles) == 0 {
+		return fmt.Errorf("no records to export")
+	}
+
+	if secretFormats[r.format] || r.secrets {
+		ok, err := utils.Confirm("The export will hold the passwords in clear text, continue?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("export canceled")
+		}
+	}
+
+	opts := options{titles: titles, fields: fields}
+	if toDir {
+		err = writeDir(r.output, db, opts)
+		if err == nil {
+			fmt.Printf("\U0001f44d %d records successfully exported to '%s'\n", len(titles), r.output)
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, db, opts); err != nil {
+		return err
+	}
+
+	if r.output == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	err = utils.WritePrivateFile(r.output, buf.Bytes())
+	if err == nil {
+		fmt.Printf("\U0001f44d %d records successfully exported to '%s'\n", len(titles), r.output)
+	}
+
+	return err
+}
+
+func (r *exportAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.format), "format", "json", "the format of the exported file")
+		fs.StringVar(&(r.output), "o", "", "the output file (or directory), the standard output if not specified")
+		fs.StringVar(&(r.group), "group", "", "export only the records of this group")
+		fs.StringVar(&(r.pattern), "pattern", "", "export only the records whose title matches this regular expression")
+		fs.StringVar(&(r.fields), "fields", "", "the comma separated fields to export")
+		fs.BoolVar(&(r.secrets), "include-secrets", false, "export the passwords too")
+	}
+}
+
+// selectedFields returns the fields to export, checking that the secrets are allowed
+func (r *exportAction) selectedFields() ([]string, error) {
+	if strings.TrimSpace(r.fields) == "" {
+		var res []string
+		for _, f := range defaultFields {
+			if r.secrets || !secretFields[f] {
+				res = append(res, f)
+			}
+		}
+		return res, nil
+	}
+
+	known := make(map[string]bool, len(exportFields))
+	for _, f := range exportFields {
+		known[f] = true
+	}
+
+	var res []string
+	for _, f := range strings.Split(r.fields, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !known[f] {
+			return nil, fmt.Errorf("unknown field '%s' - accepted values are: %s", f, strings.Join(exportFields, ", "))
+		}
+		if secretFields[f] && !r.secrets {
+			return nil, fmt.Errorf("the '%s' field can be exported only with -include-secrets", f)
+		}
+		res = append(res, f)
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no fields to export")
+	}
+	return res, nil
+}
+
+// matchingTitles returns the titles of the records in the group matching the pattern
+func (r *exportAction) matchingTitles(db pwsafe.DB, exp *regexp.Regexp) []string {
+	group := strings.TrimSpace(r.group)
+
+	var res []string
+	for _, t := range db.List() {
+		rec, _ := db.GetRecord(t)
+		if group != "" && !pwsafe.InGroup(rec.Group, group) {
+			continue
+		}
+		if exp != nil && !exp.MatchString(t) {
+			continue
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// fieldValue returns the content of the named export field of the record
+func fieldValue(rec pwsafe.Record, field string) string {
+	switch field {
+	case "uuid":
+		return uuid.UUID(rec.UUID[:]).String()
+	case "created":
+		return exportTime(rec.CreateTime)
+	case "modified":
+		return exportTime(rec.ModTime)
+	}
+
+	val, _ := rec.Field(field)
+	return val
+}
+
+// writePWSXML writes the whole store in the Password Safe XML format
+func writePWSXML(w io.Writer, db pwsafe.DB, opts options) error {
+	return pwsafe.WriteXML(db, w)
+}
+
+// formats returns the names of the supported formats
+func formats() []string {
+	res := make([]string, 0, len(writers)+len(dirWriters))
+	for k := range writers {
+		res = append(res, k)
+	}
+	for k := range dirWriters {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
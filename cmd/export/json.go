@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// jsonRecord is a JSON object holding the non empty fields in the requested order
+type jsonRecord struct {
+	fields []string
+	values []string
+}
+
+func (r jsonRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r.fields {
+		if r.values[i] == "" {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f)
+		val, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes the records as an array of objects holding the selected fields
+func writeJSON(w io.Writer, db pwsafe.DB, opts options) error {
+	res := make([]jsonRecord, 0, len(opts.titles))
+	for _, t := range opts.titles {
+		rec, _ := db.GetRecord(t)
+		// the aliases and shortcuts get the data of their base entry
+		rec, err := db.ResolveRecord(rec)
+		if err != nil {
+			return err
+		}
+
+		el := jsonRecord{fields: opts.fields, values: make([]string, len(opts.fields))}
+		for i, f := range opts.fields {
+			el.values[i] = fieldValue(rec, f)
+		}
+		res = append(res, el)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// exportTime formats the record times, the empty string if unset
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+// writePass writes the records as a password-store (the 'pass' tool) directory of plaintext
+// entries, the existing files are never overwritten
+func writePass(dir string, db pwsafe.DB, opts options) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if files, err := ioutil.ReadDir(dir); err != nil {
+		return err
+	} else if len(files) > 0 {
+		return fmt.Errorf("the directory '%s' is not empty", dir)
+	}
+
+	used := make(map[string]bool)
+	for _, t := range opts.titles {
+		rec, _ := db.GetRecord(t)
+		// pass has no references, the aliases and shortcuts get the data of their base entry
+		rec, err := db.ResolveRecord(rec)
+		if err != nil {
+			return err
+		}
+
+		parts := []string{dir}
+		for _, g := range pwsafe.SplitGroup(rec.Group) {
+			parts = append(parts, passName(g))
+		}
+		if err := os.MkdirAll(filepath.Join(parts...), 0700); err != nil {
+			return err
+		}
+
+		fn := filepath.Join(append(parts, passName(rec.Title))...)
+		for i := 2; used[strings.ToLower(fn)]; i++ {
+			fn = filepath.Join(append(parts, passName(fmt.Sprintf("%s (%d)", rec.Title, i)))...)
+		}
+		used[strings.ToLower(fn)] = true
+
+		if err := utils.WritePrivateFile(fn, []byte(utils.PassEntry(rec))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// passName returns a file name for the group or the title, without path separators
+// and not hidden since pass skips the dot files
+func passName(s string) string {
+	s = strings.NewReplacer("/", "-", "\\", "-", "\x00", "").Replace(s)
+	if strings.HasPrefix(s, ".") {
+		s = "_" + s[1:]
+	}
+	if s == "" {
+		s = "_"
+	}
+	return s
+}
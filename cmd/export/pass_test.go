@@ -0,0 +1,56 @@
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestPassName(t *testing.T) {
+	tests := map[string]string{
+		"github":      "github",
+		"a/b\\c":      "a-b-c",
+		".hidden":     "_hidden",
+		"":            "_",
+		"nul\x00byte": "nulbyte",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, passName(name), name)
+	}
+}
+
+func TestWritePass(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := pwsafe.NewV3("", "password")
+	db.SetRecord(pwsafe.Record{Title: "github", Group: "Web.Dev", Username: "john", Password: "s3cr3t", Notes: "user: x"})
+	db.SetRecord(pwsafe.Record{Title: "GitHub", Group: "Web.Dev", Password: "other"})
+	base, _ := db.GetRecord("github")
+	db.SetRecord(pwsafe.Record{Title: "github alias", Password: pwsafe.AliasReference(base)})
+
+	out := filepath.Join(dir, "pass")
+	opts := options{titles: []string{"github", "GitHub", "github alias"}}
+	assert.Nil(t, writePass(out, db, opts))
+
+	read := func(parts ...string) string {
+		data, err := ioutil.ReadFile(filepath.Join(append([]string{out}, parts...)...))
+		assert.Nil(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "s3cr3t\nuser: john\n\nuser: x\n", read("Web", "Dev", "github"))
+	// the titles differing only by case don't overwrite each other on case insensitive file systems
+	assert.Equal(t, "other\n", read("Web", "Dev", "GitHub (2)"))
+	assert.Equal(t, "s3cr3t\n", read("github alias"))
+
+	// the existing entries are never overwritten
+	assert.NotNil(t, writePass(out, db, opts))
+}
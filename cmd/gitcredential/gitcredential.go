@@ -0,0 +1,329 @@
+package gitcredential
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type gitCredentialAction struct {
+	operation string
+	group     string
+	filename  string
+}
+
+// credential holds the attributes exchanged using the git credential helper protocol
+type credential struct {
+	protocol string
+	host     string
+	path     string
+	username string
+	password string
+}
+
+const (
+	cmdName   = "git-credential"
+	shortDesc = "act as a git credential helper"
+	longDesc  = `Act as a git credential helper (get, store and erase operations).
+
+Usage: %s %s [options] get|store|erase
+
+Configure git with:
+
+  git config --global credential.helper "%s %s"
+
+ * records are matched by the host and path of their URL and by their username
+ * new credentials are stored in the group specified by -group
+ * storing the credentials of an alias updates the password of its base entry
+ * only the records of the group specified by -group are erased
+ * the secret phrase is read from the terminal, since stdin is used by git
+`
+)
+
+// NewGitCredentialCommand create a 'git-credential' cli command
+func NewGitCredentialCommand(filename string) *cli.Command {
+	action := gitCredentialAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *gitCredentialAction) handler() error {
+	switch r.operation {
+	case "get", "store", "erase":
+	case "":
+		return utils.NewMissingParameterError("operation", cmdName)
+	default:
+		// git may add new operations in the future, the protocol says to ignore them
+		return nil
+	}
+
+	cred, err := readCredential(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	if cred.host == "" {
+		return nil
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhraseFromTTY()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	switch r.operation {
+	case "get":
+		matches := matchRecords(cred, db)
+		if len(matches) == 0 {
+			return nil
+		}
+		// an alias holds the reference to the password of its base entry
+		rec, err := db.ResolveRecord(matches[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("username=%s\n", rec.Username)
+		fmt.Printf("password=%s\n", rec.Password)
+		return nil
+	case "store":
+		if cred.username == "" || cred.password == "" {
+			return nil
+		}
+		return r.store(cred, db)
+	default:
+		return r.erase(cred, db)
+	}
+}
+
+func (r *gitCredentialAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.group), "group", "Git", "the group where the git credentials are stored")
+	}
+}
+
+func (r *gitCredentialAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.operation = fs.Args()[0]
+	}
+}
+
+// store creates or updates the record matching exactly the credential
+func (r *gitCredentialAction) store(cred credential, db pwsafe.DB) error {
+	var rec pwsafe.Record
+	found := false
+	for _, el := range matchRecords(cred, db) {
+		if credentialPath(el.URL) == strings.Trim(cred.path, "/") {
+			rec, found = el, true
+			break
+		}
+	}
+
+	// an alias or a shortcut shares the password of its base entry, that is the one updated
+	if kind, baseID := rec.EntryType(); found && kind != pwsafe.NormalEntry {
+		if _, err := db.ResolveRecord(rec); err != nil {
+			return err
+		}
+		rec, _ = db.GetRecordByUUID(baseID)
+	}
+
+	if !found {
+		rec.Title = fmt.Sprintf("%s@%s", cred.username, cred.host)
+		if cred.path != "" {
+			rec.Title = fmt.Sprintf("%s/%s", rec.Title, strings.Trim(cred.path, "/"))
+		}
+		if _, exists := db.GetRecord(rec.Title); exists {
+			return fmt.Errorf("a record titled '%s' already exists", rec.Title)
+		}
+		rec.Group = r.group
+		rec.URL = cred.url()
+		rec.Username = cred.username
+	}
+
+	if rec.Password == cred.password {
+		return nil
+	}
+	rec.Password = cred.password
+
+	db.SetRecord(rec)
+	return pwsafe.WritePWSafeFile(db, r.filename)
+}
+
+// erase deletes the records of the helper group matching the credential
+func (r *gitCredentialAction) erase(cred credential, db pwsafe.DB) error {
+	removed := 0
+	for _, el := range matchRecords(cred, db) {
+		if el.Group != r.group {
+			continue
+		}
+		// the password of an alias is the one of its base entry
+		resolved, err := db.ResolveRecord(el)
+		if err != nil {
+			return err
+		}
+		if cred.password != "" && cred.password != resolved.Password {
+			continue
+		}
+		db.DeleteRecord(el.Title)
+		removed++
+	}
+
+	if removed == 0 {
+		return nil
+	}
+
+	return pwsafe.WritePWSafeFile(db, r.filename)
+}
+
+// readCredential parses the key=value lines sent by git until a blank line or EOF
+func readCredential(in io.Reader) (credential, error) {
+	var cred credential
+	scn := bufio.NewScanner(in)
+	for scn.Scan() {
+		line := scn.Text()
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			continue
+		}
+
+		key, val := line[:idx], line[idx+1:]
+		switch key {
+		case "protocol":
+			cred.protocol = val
+		case "host":
+			cred.host = strings.ToLower(val)
+		case "path":
+			cred.path = val
+		case "username":
+			cred.username = val
+		case "password":
+			cred.password = val
+		case "url":
+			u, err := utils.ParseLooseURL(val)
+			if err != nil {
+				return cred, err
+			}
+			cred.protocol = u.Scheme
+			cred.host = u.Host
+			cred.path = strings.TrimPrefix(u.Path, "/")
+			if u.User != nil {
+				cred.username = u.User.Username()
+			}
+		}
+	}
+
+	return cred, scn.Err()
+}
+
+// url returns the URL for the credential
+func (c credential) url() string {
+	protocol := c.protocol
+	if protocol == "" {
+		protocol = "https"
+	}
+
+	res := fmt.Sprintf("%s://%s", protocol, c.host)
+	if c.path != "" {
+		res = fmt.Sprintf("%s/%s", res, strings.Trim(c.path, "/"))
+	}
+	return res
+}
+
+// matchRecords returns the records matching the credential, the most specific first
+func matchRecords(cred credential, db pwsafe.DB) []pwsafe.Record {
+	type match struct {
+		rec   pwsafe.Record
+		score int
+	}
+
+	var matches []match
+	for _, t := range db.List() {
+		rec, ok := db.GetRecord(t)
+		if !ok || strings.TrimSpace(rec.URL) == "" {
+			continue
+		}
+
+		u, err := utils.ParseLooseURL(rec.URL)
+		if err != nil || u.Host != cred.host {
+			continue
+		}
+
+		if cred.protocol != "" && strings.Contains(rec.URL, "://") && u.Scheme != cred.protocol {
+			continue
+		}
+
+		if cred.username != "" && rec.Username != cred.username {
+			continue
+		}
+
+		recPath := credentialPath(rec.URL)
+		reqPath := strings.Trim(cred.path, "/")
+		if recPath != "" && reqPath != recPath && !strings.HasPrefix(reqPath, recPath+"/") {
+			continue
+		}
+
+		matches = append(matches, match{rec: rec, score: len(recPath)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	res := make([]pwsafe.Record, 0, len(matches))
+	for _, m := range matches {
+		res = append(res, m.rec)
+	}
+	return res
+}
+
+// credentialPath returns the path of the record URL without leading and trailing slashes
+func credentialPath(raw string) string {
+	u, err := utils.ParseLooseURL(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(u.Path, "/")
+}
@@ -0,0 +1,123 @@
+package gitcredential
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// newTestStore returns a store with a base entry and an alias of it, both matching github.com
+func newTestStore(t *testing.T) (pwsafe.DB, string, func()) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pwsafe.NewV3("", "password")
+	db.SetRecord(pwsafe.Record{Title: "github", Group: "Dev", URL: "https://github.com/org", Username: "john", Password: "s3cr3t"})
+	base, _ := db.GetRecord("github")
+	db.SetRecord(pwsafe.Record{Title: "github alias", Group: "Git", URL: "https://github.com/org/repo", Username: "john", Password: pwsafe.AliasReference(base)})
+
+	return db, filepath.Join(dir, "test.dat"), func() { os.RemoveAll(dir) }
+}
+
+func TestReadCredential(t *testing.T) {
+	in := "protocol=https\nhost=GitHub.com\npath=org/repo.git\nusername=john\npassword=s3cr3t\n\nignored=1\n"
+	cred, err := readCredential(strings.NewReader(in))
+	assert.Nil(t, err)
+	assert.Equal(t, credential{protocol: "https", host: "github.com", path: "org/repo.git", username: "john", password: "s3cr3t"}, cred)
+
+	cred, err = readCredential(strings.NewReader("url=https://jack@gitlab.com/group/project\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, credential{protocol: "https", host: "gitlab.com", path: "group/project", username: "jack"}, cred)
+	assert.Equal(t, "https://gitlab.com/group/project", cred.url())
+}
+
+func TestMatchRecords(t *testing.T) {
+	db, _, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var titles []string
+	for _, rec := range matchRecords(credential{protocol: "https", host: "github.com", path: "org/repo"}, db) {
+		titles = append(titles, rec.Title)
+	}
+	// the most specific first
+	assert.Equal(t, []string{"github alias", "github"}, titles)
+
+	assert.Empty(t, matchRecords(credential{protocol: "https", host: "github.com", path: "other"}, db))
+	assert.Empty(t, matchRecords(credential{protocol: "ssh", host: "github.com", path: "org"}, db))
+	assert.Empty(t, matchRecords(credential{host: "github.com", username: "jack"}, db))
+}
+
+func TestStoreAlias(t *testing.T) {
+	db, fn, cleanup := newTestStore(t)
+	defer cleanup()
+	r := gitCredentialAction{group: "Git", filename: fn}
+
+	// the same password of the base entry, nothing to store
+	cred := credential{protocol: "https", host: "github.com", path: "org/repo", username: "john", password: "s3cr3t"}
+	assert.Nil(t, r.store(cred, db))
+	_, err := os.Stat(fn)
+	assert.True(t, os.IsNotExist(err))
+
+	// a new password goes to the base entry, the alias keeps its reference
+	cred.password = "changed"
+	assert.Nil(t, r.store(cred, db))
+
+	alias, _ := db.GetRecord("github alias")
+	kind, _ := alias.EntryType()
+	assert.Equal(t, pwsafe.AliasEntry, kind)
+	base, _ := db.GetRecord("github")
+	assert.Equal(t, "changed", base.Password)
+
+	saved, err := pwsafe.OpenPWSafeFile(fn, "password")
+	if assert.Nil(t, err) {
+		rec, _ := saved.GetRecord("github alias")
+		rec, err = saved.ResolveRecord(rec)
+		assert.Nil(t, err)
+		assert.Equal(t, "changed", rec.Password)
+	}
+}
+
+func TestStoreNew(t *testing.T) {
+	db, fn, cleanup := newTestStore(t)
+	defer cleanup()
+	r := gitCredentialAction{group: "Git", filename: fn}
+
+	cred := credential{protocol: "https", host: "gitlab.com", path: "group/project", username: "jack", password: "pw"}
+	assert.Nil(t, r.store(cred, db))
+
+	rec, ok := db.GetRecord("jack@gitlab.com/group/project")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Git", rec.Group)
+		assert.Equal(t, "https://gitlab.com/group/project", rec.URL)
+		assert.Equal(t, "jack", rec.Username)
+		assert.Equal(t, "pw", rec.Password)
+	}
+}
+
+func TestEraseAlias(t *testing.T) {
+	db, fn, cleanup := newTestStore(t)
+	defer cleanup()
+	r := gitCredentialAction{group: "Git", filename: fn}
+
+	// a wrong password erases nothing
+	cred := credential{protocol: "https", host: "github.com", path: "org/repo", username: "john", password: "wrong"}
+	assert.Nil(t, r.erase(cred, db))
+	_, ok := db.GetRecord("github alias")
+	assert.True(t, ok)
+
+	// the password of the base entry erases the alias, only the records of the helper group are erased
+	cred.password = "s3cr3t"
+	assert.Nil(t, r.erase(cred, db))
+	_, ok = db.GetRecord("github alias")
+	assert.False(t, ok)
+	_, ok = db.GetRecord("github")
+	assert.True(t, ok)
+}
@@ -0,0 +1,144 @@
+package group
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type groupAction struct {
+	operation   string
+	args        []string
+	withRecords bool
+	filename    string
+}
+
+const (
+	cmdName   = "group"
+	shortDesc = "manage the groups hierarchy"
+	longDesc  = `Manage the groups hierarchy.
+
+Usage: %s %s ls [-records] [group]
+       %s %s mkdir <group>
+       %s %s mv <group> <new group>
+       %s %s rmdir <group>
+
+ * groups are nested using the dot as separator (i.e. 'Bank.Personal')
+ * a dot that is part of a group name must be escaped as '\.'
+ * mkdir creates an empty group, rmdir removes a group only if it holds no records
+ * mv renames a group moving all its records and sub groups
+`
+)
+
+// NewGroupCommand create a 'group' cli command
+func NewGroupCommand(filename string) *cli.Command {
+	action := groupAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName, bin, cmdName, bin, cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *groupAction) handler() error {
+	switch r.operation {
+	case "ls":
+	case "mkdir", "rmdir":
+		if len(r.args) < 1 || strings.TrimSpace(r.args[0]) == "" {
+			return utils.NewMissingParameterError("group", cmdName)
+		}
+	case "mv":
+		if len(r.args) < 2 {
+			return utils.NewMissingParameterError("source and destination groups", cmdName)
+		}
+	case "":
+		return utils.NewMissingParameterError("operation", cmdName)
+	default:
+		return fmt.Errorf("unknown operation '%s' - accepted values are: ls, mkdir, mv, rmdir", r.operation)
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	switch r.operation {
+	case "ls":
+		root := db.GroupTree()
+		if len(r.args) > 0 {
+			root = root.Find(r.args[0])
+			if root == nil {
+				return fmt.Errorf("group '%s' not found", r.args[0])
+			}
+		}
+		fmt.Println(utils.RenderGroupTree(root, r.withRecords, nil))
+		return nil
+	case "mkdir":
+		err = db.AddEmptyGroup(r.args[0])
+	case "rmdir":
+		err = db.DeleteEmptyGroup(r.args[0])
+	case "mv":
+		var moved int
+		moved, err = db.MoveGroup(r.args[0], r.args[1])
+		if err == nil {
+			fmt.Printf("%d records moved to group '%s'\n", moved, r.args[1])
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = pwsafe.WritePWSafeFile(db, r.filename)
+	if err == nil {
+		fmt.Printf("\U0001f44d groups successfully updated in store '%s'\n", r.filename)
+	}
+
+	return err
+}
+
+func (r *groupAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.BoolVar(&(r.withRecords), "records", false, "list the records too")
+	}
+}
+
+func (r *groupAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.operation = fs.Args()[0]
+		r.args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,30 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vector from RFC 9106 section 5.1
+func TestArgon2dKey(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	key := argon2dKey(password, salt, secret, data, 3, 32, 4, 32)
+	assert.Equal(t, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb", hex.EncodeToString(key))
+}
+
+func TestArgon2dKeyParameters(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+
+	assert.NotEqual(t, argon2dKey(password, salt, nil, nil, 2, 64, 2, 32), argon2dKey(password, salt, nil, nil, 2, 64, 1, 32))
+
+	// the keys as long as and longer than a BLAKE2b digest
+	assert.Equal(t, 64, len(argon2dKey(password, salt, nil, nil, 1, 16, 1, 64)))
+	assert.Equal(t, 100, len(argon2dKey(password, salt, nil, nil, 1, 16, 1, 100)))
+}
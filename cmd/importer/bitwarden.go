@@ -0,0 +1,260 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// the Bitwarden item types
+const (
+	bitwardenLogin = iota + 1
+	bitwardenSecureNote
+	bitwardenCard
+	bitwardenIdentity
+	bitwardenSSHKey
+)
+
+// the Bitwarden custom field types
+const (
+	bitwardenFieldText = iota
+	bitwardenFieldHidden
+	bitwardenFieldBoolean
+	bitwardenFieldLinked
+)
+
+type bitwardenExport struct {
+	Encrypted   bool              `json:"encrypted"`
+	Folders     []bitwardenFolder `json:"folders"`
+	Collections []bitwardenFolder `json:"collections"`
+	Items       []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	ID              string                 `json:"id"`
+	FolderID        string                 `json:"folderId"`
+	CollectionIDs   []string               `json:"collectionIds"`
+	Type            int                    `json:"type"`
+	Name            string                 `json:"name"`
+	Notes           string                 `json:"notes"`
+	Fields          []bitwardenField       `json:"fields"`
+	Login           *bitwardenLoginData    `json:"login"`
+	Card            map[string]interface{} `json:"card"`
+	Identity        map[string]interface{} `json:"identity"`
+	SSHKey          map[string]interface{} `json:"sshKey"`
+	PasswordHistory []struct {
+		LastUsedDate string `json:"lastUsedDate"`
+		Password     string `json:"password"`
+	} `json:"passwordHistory"`
+	CreationDate string `json:"creationDate"`
+	RevisionDate string `json:"revisionDate"`
+	DeletedDate  string `json:"deletedDate"`
+}
+
+type bitwardenLoginData struct {
+	URIs []struct {
+		URI string `json:"uri"`
+	} `json:"uris"`
+	Username             string            `json:"username"`
+	Password             string            `json:"password"`
+	TOTP                 string            `json:"totp"`
+	PasswordRevisionDate string            `json:"passwordRevisionDate"`
+	Fido2Credentials     []json.RawMessage `json:"fido2Credentials"`
+}
+
+type bitwardenField struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Type  int         `json:"type"`
+}
+
+// the labels of the card and identity fields, in the order they are added to the notes
+var (
+	bitwardenCardFields = []string{
+		"brand", "Brand",
+	}
+	bitwardenIdentityFields = []string{
+		"title", "Title", "firstName", "First name", "middleName", "Middle name", "lastName", "Last name",
+		"company", "Company", "phone", "Phone", "address1", "Address", "address2", "Address 2",
+		"address3", "Address 3", "city", "City", "state", "State", "postalCode", "Postal code", "country", "Country",
+		"ssn", "SSN", "passportNumber", "Passport number", "licenseNumber", "License number",
+	}
+	bitwardenSSHKeyFields = []string{"publicKey", "Public key", "keyFingerprint", "Fingerprint"}
+)
+
+// readBitwardenJSON reads the records from an unencrypted Bitwarden JSON export
+func readBitwardenJSON(fn string, opts options) (*source, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Bitwarden JSON export - %s", err.Error())
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("encrypted Bitwarden exports are not supported, export the vault as unencrypted JSON")
+	}
+
+	// folders for the personal vaults, collections for the organization ones
+	groups := make(map[string]string)
+	for _, f := range append(export.Folders, export.Collections...) {
+		groups[f.ID] = pwsafe.JoinGroup(strings.Split(f.Name, "/")...)
+	}
+
+	res := &source{}
+	for _, item := range export.Items {
+		if item.DeletedDate != "" {
+			res.unmapped = append(res.unmapped, fmt.Sprintf("'%s': in the trash, skipped", item.Name))
+			continue
+		}
+
+		group := groups[item.FolderID]
+		if group == "" && len(item.CollectionIDs) > 0 {
+			group = groups[item.CollectionIDs[0]]
+		}
+		rec, unmapped := bitwardenRecord(item, group)
+		res.records = append(res.records, rec)
+		res.unmapped = append(res.unmapped, unmapped...)
+	}
+
+	return res, nil
+}
+
+// bitwardenRecord maps an item to a record, returning what could not be mapped
+func bitwardenRecord(item bitwardenItem, group string) (pwsafe.Record, []string) {
+	rec := pwsafe.Record{
+		Title:      strings.TrimSpace(item.Name),
+		Group:      group,
+		Notes:      item.Notes,
+		CreateTime: parseJSONTime(item.CreationDate),
+		ModTime:    parseJSONTime(item.RevisionDate),
+	}
+
+	var (
+		extra    []string
+		unmapped []string
+	)
+	report := func(format string, args ...interface{}) {
+		unmapped = append(unmapped, fmt.Sprintf("'%s': ", rec.Title)+fmt.Sprintf(format, args...))
+	}
+
+	switch item.Type {
+	case bitwardenLogin:
+		if l := item.Login; l != nil {
+			rec.Username = strings.TrimSpace(l.Username)
+			rec.Password = l.Password
+			for i, u := range l.URIs {
+				if i == 0 {
+					rec.URL = strings.TrimSpace(u.URI)
+				} else {
+					extra = append(extra, fmt.Sprintf("URL: %s", u.URI))
+				}
+			}
+			if l.TOTP != "" {
+				if err := setOTP(&rec, l.TOTP); err != nil {
+					extra = append(extra, fmt.Sprintf("TOTP: %s", l.TOTP))
+					report("invalid TOTP seed kept in the notes (%s)", err.Error())
+				}
+			}
+			if t := parseJSONTime(l.PasswordRevisionDate); !t.IsZero() {
+				rec.SetPasswordModified(t)
+			}
+			if len(l.Fido2Credentials) > 0 {
+				report("passkeys are not supported, skipped")
+			}
+		}
+	case bitwardenSecureNote:
+		report("secure note, the password is the placeholder '%s'", pwsafe.PlaceholderPassword)
+	case bitwardenCard:
+		rec.Username = jsonString(item.Card["cardholderName"])
+		rec.CreditCardNumber = jsonString(item.Card["number"])
+		rec.CreditCardVerifValue = jsonString(item.Card["code"])
+		rec.CreditCardExpiration = cardExpiration(jsonString(item.Card["expMonth"]), jsonString(item.Card["expYear"]))
+		extra = append(extra, labeledValues(item.Card, bitwardenCardFields)...)
+		report("card, stored with the placeholder password '%s'", pwsafe.PlaceholderPassword)
+	case bitwardenIdentity:
+		rec.Username = jsonString(item.Identity["username"])
+		rec.Email = jsonString(item.Identity["email"])
+		extra = append(extra, labeledValues(item.Identity, bitwardenIdentityFields)...)
+		report("identity, stored in the notes with the placeholder password '%s'", pwsafe.PlaceholderPassword)
+	case bitwardenSSHKey:
+		rec.Password = jsonString(item.SSHKey["privateKey"])
+		extra = append(extra, labeledValues(item.SSHKey, bitwardenSSHKeyFields)...)
+		report("SSH key, the private key is the password and the public key is in the notes")
+	default:
+		report("unknown item type %d, only the notes are imported", item.Type)
+	}
+
+	for _, f := range item.Fields {
+		if f.Type == bitwardenFieldLinked {
+			report("linked field '%s' skipped", f.Name)
+			continue
+		}
+		extra = append(extra, fmt.Sprintf("%s: %s", f.Name, jsonString(f.Value)))
+	}
+	addNotes(&rec, extra)
+
+	var history []pwsafe.PasswordHistoryEntry
+	for _, h := range item.PasswordHistory {
+		history = append(history, pwsafe.PasswordHistoryEntry{Time: parseJSONTime(h.LastUsedDate), Password: h.Password})
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+	rec.SetPasswordHistory(history)
+
+	if rec.Password == "" && item.Type != bitwardenLogin {
+		rec.Password = pwsafe.PlaceholderPassword
+	}
+
+	return rec, unmapped
+}
+
+// labeledValues returns the 'Label: value' lines of the non empty values,
+// fields holds the pairs of keys and labels
+func labeledValues(values map[string]interface{}, fields []string) []string {
+	var res []string
+	for i := 0; i < len(fields); i += 2 {
+		if val := jsonString(values[fields[i]]); val != "" {
+			res = append(res, fmt.Sprintf("%s: %s", fields[i+1], val))
+		}
+	}
+	return res
+}
+
+// jsonString returns the JSON value as a string, the empty string for null
+func jsonString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%v", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	}
+	b, _ := json.Marshal(val)
+	return string(b)
+}
+
+// parseJSONTime parses the RFC 3339 times of the JSON exports, the zero time if invalid
+func parseJSONTime(val string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// mappedFields returns the record with only the fields mapped by the importers,
+// so that the expected records don't have to tell the times and the history
+func mappedFields(rec pwsafe.Record) pwsafe.Record {
+	return pwsafe.Record{
+		Title:                rec.Title,
+		Group:                rec.Group,
+		Username:             rec.Username,
+		Password:             rec.Password,
+		URL:                  rec.URL,
+		Email:                rec.Email,
+		Notes:                rec.Notes,
+		CreditCardNumber:     rec.CreditCardNumber,
+		CreditCardExpiration: rec.CreditCardExpiration,
+		CreditCardVerifValue: rec.CreditCardVerifValue,
+		CreditCardPIN:        rec.CreditCardPIN,
+	}
+}
+
+func TestBitwardenRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		want     pwsafe.Record
+		unmapped int
+	}{
+		{
+			name: "login",
+			item: `{"type": 1, "name": " github ", "notes": "a note",
+				"fields": [{"name": "pin", "value": "1234", "type": 1}, {"name": "linked", "value": null, "type": 3}],
+				"login": {"uris": [{"uri": "https://github.com"}, {"uri": "https://gist.github.com"}],
+					"username": "john", "password": "s3cr3t", "totp": "JBSWY3DPEHPK3PXP"}}`,
+			want: pwsafe.Record{
+				Title: "github", Username: "john", Password: "s3cr3t", URL: "https://github.com",
+				Notes: "a note\n\nURL: https://gist.github.com\npin: 1234",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "secure note",
+			item: `{"type": 2, "name": "wifi", "notes": "the password is on the router"}`,
+			want: pwsafe.Record{
+				Title: "wifi", Password: pwsafe.PlaceholderPassword, Notes: "the password is on the router",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "card",
+			item: `{"type": 3, "name": "visa", "card": {"cardholderName": "John Doe", "brand": "Visa",
+				"number": "4111111111111111", "expMonth": "3", "expYear": "2030", "code": "123"}}`,
+			want: pwsafe.Record{
+				Title: "visa", Username: "John Doe", Password: pwsafe.PlaceholderPassword, Notes: "Brand: Visa",
+				CreditCardNumber: "4111111111111111", CreditCardExpiration: "03/2030", CreditCardVerifValue: "123",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "identity",
+			item: `{"type": 4, "name": "me", "identity": {"firstName": "John", "lastName": "Doe",
+				"username": "jdoe", "email": "john@doe.com", "phone": null}}`,
+			want: pwsafe.Record{
+				Title: "me", Username: "jdoe", Email: "john@doe.com", Password: pwsafe.PlaceholderPassword,
+				Notes: "First name: John\nLast name: Doe",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "ssh key",
+			item: `{"type": 5, "name": "server", "sshKey": {"privateKey": "-----BEGIN KEY-----", "publicKey": "ssh-ed25519 AAAA"}}`,
+			want: pwsafe.Record{
+				Title: "server", Password: "-----BEGIN KEY-----", Notes: "Public key: ssh-ed25519 AAAA",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "unknown type",
+			item: `{"type": 42, "name": "future", "notes": "kept"}`,
+			want: pwsafe.Record{
+				Title: "future", Password: pwsafe.PlaceholderPassword, Notes: "kept",
+			},
+			unmapped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item bitwardenItem
+			if err := json.Unmarshal([]byte(tt.item), &item); err != nil {
+				t.Fatal(err)
+			}
+
+			rec, unmapped := bitwardenRecord(item, "")
+			assert.Equal(t, tt.want, mappedFields(rec))
+			assert.Len(t, unmapped, tt.unmapped)
+		})
+	}
+}
+
+func TestBitwardenRecordHistory(t *testing.T) {
+	var item bitwardenItem
+	err := json.Unmarshal([]byte(`{"type": 1, "name": "github",
+		"creationDate": "2020-01-02T03:04:05.000Z", "revisionDate": "2022-01-02T03:04:05.000Z",
+		"login": {"password": "new", "passwordRevisionDate": "2021-01-02T03:04:05.000Z"},
+		"passwordHistory": [{"lastUsedDate": "2020-06-01T00:00:00.000Z", "password": "newer"},
+			{"lastUsedDate": "2020-03-01T00:00:00.000Z", "password": "old"}]}`), &item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _ := bitwardenRecord(item, "Web")
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), rec.CreateTime)
+	assert.Equal(t, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), rec.ModTime)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), rec.PasswordModified().UTC())
+
+	history := rec.PasswordHistoryEntries()
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, "old", history[0].Password)
+		assert.Equal(t, "newer", history[1].Password)
+	}
+}
+
+func TestReadBitwardenJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "bitwarden.json")
+	err = ioutil.WriteFile(fn, []byte(`{"encrypted": false,
+		"folders": [{"id": "f1", "name": "Web/Social"}],
+		"collections": [{"id": "c1", "name": "Shared"}],
+		"items": [
+			{"type": 1, "name": "twitter", "folderId": "f1", "login": {"password": "a"}},
+			{"type": 1, "name": "team", "collectionIds": ["c1"], "login": {"password": "b"}},
+			{"type": 1, "name": "gone", "deletedDate": "2022-01-02T03:04:05.000Z", "login": {"password": "c"}}
+		]}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := readBitwardenJSON(fn, options{})
+	if assert.Nil(t, err) && assert.Len(t, src.records, 2) {
+		assert.Equal(t, "Web.Social", src.records[0].Group)
+		assert.Equal(t, "Shared", src.records[1].Group)
+		assert.Equal(t, []string{"'gone': in the trash, skipped"}, src.unmapped)
+	}
+
+	if err := ioutil.WriteFile(fn, []byte(`{"encrypted": true, "items": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = readBitwardenJSON(fn, options{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,221 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// csvFields are the record fields a CSV column can be mapped to
+var csvFields = []string{"title", "group", "user", "pass", "url", "notes", "email", "otp"}
+
+// csvLayout is a well known CSV export layout, the columns are matched case insensitive
+type csvLayout struct {
+	name    string
+	columns map[string]string // record field -> column name
+}
+
+var csvLayouts = []csvLayout{
+	{
+		name: "Bitwarden",
+		columns: map[string]string{
+			"title": "name", "group": "folder", "user": "login_username", "pass": "login_password",
+			"url": "login_uri", "notes": "notes", "otp": "login_totp",
+		},
+	},
+	{
+		name: "Firefox",
+		columns: map[string]string{
+			"user": "username", "pass": "password", "url": "url",
+		},
+	},
+	{
+		name: "Chrome",
+		columns: map[string]string{
+			"title": "name", "user": "username", "pass": "password", "url": "url",
+		},
+	},
+}
+
+// csvAliases are the column names recognized for the fields not mapped by a layout
+var csvAliases = map[string][]string{
+	"title": {"title", "name", "account"},
+	"group": {"group", "category", "folder"},
+	"user":  {"user", "username", "login", "login_username"},
+	"pass":  {"pass", "password", "login_password"},
+	"url":   {"url", "uri", "website", "login_uri"},
+	"notes": {"notes", "note", "extra", "comments"},
+	"email": {"email", "e-mail", "mail"},
+	"otp":   {"otp", "totp", "login_totp"},
+}
+
+// parseMapping parses the 'field=column,...' mapping option
+func parseMapping(val string) (map[string]string, error) {
+	res := make(map[string]string)
+	if strings.TrimSpace(val) == "" {
+		return res, nil
+	}
+
+	for _, el := range strings.Split(val, ",") {
+		parts := strings.SplitN(el, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid mapping '%s' - expected field=column", el)
+		}
+
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !contains(csvFields, field) {
+			return nil, fmt.Errorf("unknown field '%s' - accepted values are: %s", field, strings.Join(csvFields, ", "))
+		}
+		res[field] = strings.TrimSpace(parts[1])
+	}
+
+	return res, nil
+}
+
+// readCSV reads the records from a CSV file, the first line must be the header
+func readCSV(fn string, opts options) (*source, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rd := csv.NewReader(f)
+	rd.FieldsPerRecord = -1
+
+	header, err := rd.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header - %s", err.Error())
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	index, layout, err := csvColumns(header, opts.mapping)
+	if err != nil {
+		return nil, err
+	}
+	if layout != "" {
+		fmt.Printf("detected %s CSV layout\n", layout)
+	}
+
+	var res []pwsafe.Record
+	for line := 2; ; line++ {
+		row, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(field string) string {
+			if i, ok := index[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		rec := pwsafe.Record{
+			Title:    value("title"),
+			Group:    value("group"),
+			Username: value("user"),
+			Password: value("pass"),
+			URL:      value("url"),
+			Notes:    value("notes"),
+			Email:    value("email"),
+		}
+		if layout == "Bitwarden" && rec.Group != "" {
+			rec.Group = pwsafe.JoinGroup(strings.Split(rec.Group, "/")...)
+		}
+
+		if otp := value("otp"); otp != "" {
+			if err := setOTP(&rec, otp); err != nil {
+				fmt.Printf("line %d: %s, OTP ignored\n", line, err.Error())
+			}
+		}
+
+		res = append(res, rec)
+	}
+
+	return &source{records: res}, nil
+}
+
+// csvColumns returns the index of the column of each field and the name of the detected layout
+func csvColumns(header []string, mapping map[string]string) (map[string]int, string, error) {
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	index := make(map[string]int)
+	layout := ""
+	for _, l := range csvLayouts {
+		if matchLayout(l, columns) {
+			layout = l.name
+			for field, col := range l.columns {
+				index[field] = columns[col]
+			}
+			break
+		}
+	}
+
+	for field, names := range csvAliases {
+		if _, ok := index[field]; ok {
+			continue
+		}
+		for _, n := range names {
+			if i, ok := columns[n]; ok {
+				index[field] = i
+				break
+			}
+		}
+	}
+
+	for field, col := range mapping {
+		i, ok := columns[strings.ToLower(col)]
+		if !ok {
+			return nil, "", fmt.Errorf("column '%s' not found in the CSV header", col)
+		}
+		index[field] = i
+	}
+
+	if _, ok := index["pass"]; !ok {
+		return nil, "", fmt.Errorf("no password column found - use -map pass=<column>")
+	}
+	if _, ok := index["title"]; !ok {
+		if _, ok := index["url"]; !ok {
+			return nil, "", fmt.Errorf("no title or url column found - use -map title=<column>")
+		}
+	}
+
+	return index, layout, nil
+}
+
+// matchLayout tells if all the columns of the layout are in the header
+func matchLayout(l csvLayout, columns map[string]int) bool {
+	for _, col := range l.columns {
+		if _, ok := columns[col]; !ok {
+			return false
+		}
+	}
+	// firefox exports have no 'name' column but always a 'guid' one
+	if l.name == "Firefox" {
+		_, ok := columns["guid"]
+		return ok
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
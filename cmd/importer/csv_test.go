@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestReadCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		mapping map[string]string
+		want    []pwsafe.Record
+	}{
+		{
+			name: "bitwarden",
+			content: "folder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password,login_totp\n" +
+				"Web/Social,,login,github,a note,,0,https://github.com,john,s3cr3t,\n",
+			want: []pwsafe.Record{
+				{Title: "github", Group: "Web.Social", Username: "john", Password: "s3cr3t", URL: "https://github.com", Notes: "a note"},
+			},
+		},
+		{
+			name: "firefox",
+			content: "url,username,password,httpRealm,formActionOrigin,guid,timeCreated,timePasswordChanged,timeLastUsed\n" +
+				"https://gitlab.com,jack,p4ss,,https://gitlab.com,{0001},1,1,1\n",
+			want: []pwsafe.Record{
+				{Username: "jack", Password: "p4ss", URL: "https://gitlab.com"},
+			},
+		},
+		{
+			name:    "chrome",
+			content: "\ufeffname,url,username,password\ngitlab.com,https://gitlab.com/users/sign_in,jack,p4ss\n",
+			want: []pwsafe.Record{
+				{Title: "gitlab.com", Username: "jack", Password: "p4ss", URL: "https://gitlab.com/users/sign_in"},
+			},
+		},
+		{
+			name:    "aliases",
+			content: "Account,Category,Login,Password,Website,Comments,E-mail\nbank, Money ,john,m0n3y,https://bank.com,pin 1234,john@bank.com\n",
+			want: []pwsafe.Record{
+				{Title: "bank", Group: "Money", Username: "john", Password: "m0n3y", URL: "https://bank.com", Notes: "pin 1234", Email: "john@bank.com"},
+			},
+		},
+		{
+			name:    "mapping",
+			content: "site,secret,who,name\nrouter,adm1n,root,not the title\n",
+			mapping: map[string]string{"title": "site", "pass": "Secret", "user": "who"},
+			want: []pwsafe.Record{
+				{Title: "router", Username: "root", Password: "adm1n"},
+			},
+		},
+		{
+			name:    "short rows",
+			content: "title,password,notes\nfirst,one\nsecond,two,more\n",
+			want: []pwsafe.Record{
+				{Title: "first", Password: "one"},
+				{Title: "second", Password: "two", Notes: "more"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(dir, tt.name+".csv")
+			if err := ioutil.WriteFile(fn, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := readCSV(fn, options{mapping: tt.mapping})
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.want, src.records)
+			}
+		})
+	}
+}
+
+func TestCSVColumnsErrors(t *testing.T) {
+	_, _, err := csvColumns([]string{"title", "user"}, nil)
+	assert.EqualError(t, err, "no password column found - use -map pass=<column>")
+
+	_, _, err = csvColumns([]string{"user", "password"}, nil)
+	assert.EqualError(t, err, "no title or url column found - use -map title=<column>")
+
+	_, _, err = csvColumns([]string{"title", "password"}, map[string]string{"user": "missing"})
+	assert.EqualError(t, err, "column 'missing' not found in the CSV header")
+}
+
+func TestParseMapping(t *testing.T) {
+	res, err := parseMapping(" title = name ,PASS=secret")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"title": "name", "pass": "secret"}, res)
+
+	_, err = parseMapping("title")
+	assert.NotNil(t, err)
+
+	_, err = parseMapping("color=red")
+	assert.NotNil(t, err)
+}
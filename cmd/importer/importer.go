@@ -0,0 +1,236 @@
+package importer
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type importAction struct {
+	source   string
+	format   string
+	mapping  string
+	conflict string
+	group    string
+	keyFile  string
+	dryRun   bool
+	filename string
+}
+
+// options are the format specific import options
+type options struct {
+	mapping map[string]string
+	keyFile string
+}
+
+// source is the content read from the file to import
+type source struct {
+	records     []pwsafe.Record
+	emptyGroups []string
+	policies    []pwsafe.PasswordPolicy
+	unmapped    []string // what could not be mapped to the record fields
+}
+
+// reader reads the records to import from the source file
+type reader func(fn string, opts options) (*source, error)
+
+var readers = map[string]reader{
+	"1pux":           readOnePux,
+	"bitwarden-json": readBitwardenJSON,
+	"csv":            readCSV,
+	"kdbx":           readKDBX,
+	"pass":           readPass,
+	"pwsxml":         readPWSXML,
+}
+
+const (
+	cmdName   = "import"
+	shortDesc = "import records from other password managers"
+	longDesc  = `Import the records exported by other password managers.
+
+Usage: %s %s -format csv [-map title=name,user=login,...] [-dry-run] <file>
+       %s %s -format kdbx [-keyfile <key file>] [-dry-run] <file>
+       %s %s -format pwsxml|bitwarden-json|1pux [-dry-run] <file>
+       %s %s -format pass [-dry-run] <directory>
+
+ * accepted values for 'format' are: %s
+ * the Chrome, Firefox and Bitwarden CSV layouts are detected from the header,
+   for other layouts use -map to tell the column of each field
+   (title, group, user, pass, url, notes, email, otp)
+ * KeePass KDBX 3.1 and 4 databases are decrypted asking for their password,
+   the custom fields are added to the notes, times and password history are kept
+ * Bitwarden (unencrypted JSON) and 1Password (1PUX) exports map folders and vaults
+   to groups, the TOTP seeds to the two factor fields, cards to the credit card fields,
+   custom fields, secure notes and identities to the notes: at the end a report lists
+   what could not be mapped
+ * pass reads a password-store directory of already decrypted entries: the directories
+   are the groups, the file names the titles, the first line is the password and the
+   'user:', 'url:', 'email:' and otpauth:// lines are mapped to the fields, the lines
+   after the first empty one are always notes
+ * Password Safe XML files keep all the record fields, the empty groups and the
+   named password policies are added too
+ * records with the same username, URL and password of an existing one are skipped
+ * when a record with the same title already exists -conflict tells what to do:
+   skip (default), overwrite or rename
+ * with -group the records are imported under the specified group
+ * with -dry-run the records are only listed, nothing is saved
+`
+)
+
+// NewImportCommand create a 'import' cli command
+func NewImportCommand(filename string) *cli.Command {
+	action := importAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName, bin, cmdName, bin, cmdName, strings.Join(formats(), ", ")),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *importAction) handler() error {
+	if strings.TrimSpace(r.source) == "" {
+		return utils.NewMissingParameterError("file to import", cmdName)
+	}
+
+	read, ok := readers[r.format]
+	if !ok {
+		return fmt.Errorf("unknown format '%s' - accepted values are: %s", r.format, strings.Join(formats(), ", "))
+	}
+
+	switch r.conflict {
+	case "skip", "overwrite", "rename":
+	default:
+		return fmt.Errorf("invalid conflict mode '%s' - accepted values are: skip, overwrite, rename", r.conflict)
+	}
+
+	mapping, err := parseMapping(r.mapping)
+	if err != nil {
+		return err
+	}
+	if len(mapping) > 0 && r.format != "csv" {
+		return fmt.Errorf("-map can be used only with the csv format")
+	}
+
+	if r.keyFile != "" && r.format != "kdbx" {
+		return fmt.Errorf("-keyfile can be used only with the kdbx format")
+	}
+
+	src, err := read(r.source, options{mapping: mapping, keyFile: r.keyFile})
+	if err != nil {
+		return err
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	group := strings.TrimSpace(r.group)
+	entries := planImport(src.records, db, r.conflict, group)
+	if r.dryRun {
+		fmt.Println(dumpPlan(entries))
+		fmt.Println(summary(entries))
+		fmt.Print(unmappedReport(src))
+		if len(src.emptyGroups) > 0 || len(src.policies) > 0 {
+			fmt.Printf("%d empty groups and %d named password policies read\n", len(src.emptyGroups), len(src.policies))
+		}
+		return nil
+	}
+
+	saved := 0
+	for _, el := range entries {
+		switch el.action {
+		case actionSkip:
+			continue
+		case actionOverwrite:
+			db.SetRecord(el.record)
+		default:
+			// new records keep the times read from the imported file
+			db.ImportRecord(el.record)
+		}
+		saved++
+	}
+	fmt.Println(summary(entries))
+	fmt.Print(unmappedReport(src))
+
+	groups, policies, err := importHeader(src, db, r.conflict, group)
+	if err != nil {
+		return err
+	}
+	if groups > 0 || policies > 0 {
+		fmt.Printf("%d empty groups and %d named password policies added\n", groups, policies)
+	}
+
+	if saved == 0 && groups == 0 && policies == 0 {
+		return nil
+	}
+
+	err = pwsafe.WritePWSafeFile(db, r.filename)
+	if err == nil {
+		fmt.Printf("\U0001f44d %d records successfully imported to store '%s'\n", saved, r.filename)
+	}
+
+	return err
+}
+
+func (r *importAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.format), "format", "csv", "the format of the file to import")
+		fs.StringVar(&(r.mapping), "map", "", "the CSV columns of the fields as field=column,...")
+		fs.StringVar(&(r.conflict), "conflict", "skip", "what to do when the title already exists: skip, overwrite, rename")
+		fs.StringVar(&(r.group), "group", "", "import the records under this group")
+		fs.StringVar(&(r.keyFile), "keyfile", "", "the KeePass key file")
+		fs.BoolVar(&(r.dryRun), "dry-run", false, "only show what would be imported")
+	}
+}
+
+func (r *importAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.source = fs.Args()[0]
+	}
+}
+
+// formats returns the names of the supported formats
+func formats() []string {
+	res := make([]string, 0, len(readers))
+	for k := range readers {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,606 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+	"golang.org/x/crypto/twofish"
+)
+
+// The KeePass 2.x database format, see https://keepass.info/help/kb/kdbx_4.html
+
+const (
+	kdbxSignature1   = 0x9AA2D903
+	kdbxSignature2   = 0xB54BFB67
+	kdb1xSignature2  = 0xB54BFB65
+	kdbxEndOfHeader  = 0
+	kdbxCipherID     = 2
+	kdbxCompression  = 3
+	kdbxMasterSeed   = 4
+	kdbxTransformSd  = 5
+	kdbxTransformRd  = 6
+	kdbxEncryptionIV = 7
+	kdbxStreamKey    = 8
+	kdbxStreamStart  = 9
+	kdbxStreamID     = 10
+	kdbxKdfParams    = 11
+
+	kdbxInnerEnd      = 0
+	kdbxInnerStreamID = 1
+	kdbxInnerKey      = 2
+
+	kdbxSalsa20  = 2
+	kdbxChaCha20 = 3
+)
+
+var (
+	kdbxAES256   = mustDecodeHex("31c1f2e6bf714350be5805216afc5aff")
+	kdbxChaCha   = mustDecodeHex("d6038a2b8b6f4cb5a524339a31dbb59a")
+	kdbxTwofish  = mustDecodeHex("ad68f29f576f4bb9a36ad47af965346c")
+	kdfAES       = mustDecodeHex("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfAES3      = mustDecodeHex("7c02bb8279a74ac0927d114a00648238")
+	kdfArgon2d   = mustDecodeHex("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id  = mustDecodeHex("9e298b1956db4773b23dfc3ec6f0a1e6")
+	salsa20Nonce = []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}
+
+	errKDBXCredentials = errors.New("invalid KeePass credentials or corrupted database")
+)
+
+// kdbxHeader holds the outer header fields
+type kdbxHeader struct {
+	major       uint16
+	cipherID    []byte
+	compressed  bool
+	masterSeed  []byte
+	iv          []byte
+	kdf         map[string]interface{}
+	streamKey   []byte
+	streamStart []byte
+	streamID    uint32
+}
+
+// decryptKDBX returns the XML document of a KDBX 3.1 or 4 database
+func decryptKDBX(data []byte, password string, keyFile []byte) ([]byte, *kdbxStream, error) {
+	rd := bytes.NewReader(data)
+	var sig [3]uint32
+	if err := binary.Read(rd, binary.LittleEndian, &sig); err != nil {
+		return nil, nil, fmt.Errorf("not a KeePass database")
+	}
+	if sig[0] != kdbxSignature1 || (sig[1] != kdbxSignature2 && sig[1] != kdb1xSignature2) {
+		return nil, nil, fmt.Errorf("not a KeePass database")
+	}
+	if sig[1] == kdb1xSignature2 {
+		return nil, nil, fmt.Errorf("KeePass 1.x databases are not supported")
+	}
+
+	h := kdbxHeader{major: uint16(sig[2] >> 16)}
+	if h.major != 3 && h.major != 4 {
+		return nil, nil, fmt.Errorf("unsupported KDBX version %d.%d", h.major, sig[2]&0xFFFF)
+	}
+
+	if err := h.read(rd); err != nil {
+		return nil, nil, err
+	}
+	headerLen := len(data) - rd.Len()
+
+	composite, err := compositeKey(password, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	transformed, err := h.transformKey(composite)
+	if err != nil {
+		return nil, nil, err
+	}
+	masterKey := sha256.Sum256(append(append([]byte{}, h.masterSeed...), transformed...))
+
+	var payload []byte
+	if h.major == 4 {
+		hmacBase := sha512.Sum512(append(append(append([]byte{}, h.masterSeed...), transformed...), 1))
+		payload, err = readKDBX4Payload(data[:headerLen], data[headerLen:], hmacBase[:])
+	} else {
+		payload = data[headerLen:]
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plain, err := h.decrypt(masterKey[:], payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if h.major == 3 {
+		if len(plain) < 32 || !bytes.Equal(plain[:32], h.streamStart) {
+			return nil, nil, errKDBXCredentials
+		}
+		plain, err = readHashedBlocks(plain[32:])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if h.compressed {
+		zr, err := gzip.NewReader(bytes.NewReader(plain))
+		if err != nil {
+			return nil, nil, err
+		}
+		plain, err = ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if h.major == 4 {
+		plain, err = h.readInnerHeader(plain)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stream, err := newKDBXStream(h.streamID, h.streamKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plain, stream, nil
+}
+
+// read parses the outer header fields
+func (h *kdbxHeader) read(rd *bytes.Reader) error {
+	for {
+		id, err := rd.ReadByte()
+		if err != nil {
+			return fmt.Errorf("invalid KeePass header")
+		}
+
+		var size uint32
+		if h.major == 4 {
+			err = binary.Read(rd, binary.LittleEndian, &size)
+		} else {
+			var size16 uint16
+			err = binary.Read(rd, binary.LittleEndian, &size16)
+			size = uint32(size16)
+		}
+		if err != nil || int(size) > rd.Len() {
+			return fmt.Errorf("invalid KeePass header")
+		}
+
+		val := make([]byte, size)
+		rd.Read(val)
+
+		switch id {
+		case kdbxEndOfHeader:
+			return h.validate()
+		case kdbxCipherID:
+			h.cipherID = val
+		case kdbxCompression:
+			h.compressed = len(val) >= 4 && binary.LittleEndian.Uint32(val) == 1
+		case kdbxMasterSeed:
+			h.masterSeed = val
+		case kdbxTransformSd:
+			h.aesKDF()["S"] = val
+		case kdbxTransformRd:
+			if len(val) >= 8 {
+				h.aesKDF()["R"] = binary.LittleEndian.Uint64(val)
+			}
+		case kdbxEncryptionIV:
+			h.iv = val
+		case kdbxStreamKey:
+			h.streamKey = val
+		case kdbxStreamStart:
+			h.streamStart = val
+		case kdbxStreamID:
+			if len(val) >= 4 {
+				h.streamID = binary.LittleEndian.Uint32(val)
+			}
+		case kdbxKdfParams:
+			h.kdf, err = readVariantDictionary(val)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// aesKDF returns the KDF parameters of a KDBX 3 header, always AES-KDF
+func (h *kdbxHeader) aesKDF() map[string]interface{} {
+	if h.kdf == nil {
+		h.kdf = map[string]interface{}{"$UUID": kdfAES}
+	}
+	return h.kdf
+}
+
+func (h *kdbxHeader) validate() error {
+	if len(h.masterSeed) != 32 || len(h.iv) == 0 || h.kdf == nil {
+		return fmt.Errorf("invalid KeePass header, missing fields")
+	}
+	if h.major == 3 && len(h.streamStart) != 32 {
+		return fmt.Errorf("invalid KeePass header, missing stream start bytes")
+	}
+	return nil
+}
+
+// transformKey derives the key from the composite key using the KDF of the header
+func (h *kdbxHeader) transformKey(composite []byte) ([]byte, error) {
+	id, _ := h.kdf["$UUID"].([]byte)
+	salt, _ := h.kdf["S"].([]byte)
+
+	switch {
+	case bytes.Equal(id, kdfAES), bytes.Equal(id, kdfAES3):
+		rounds, _ := h.kdf["R"].(uint64)
+		if len(salt) != 32 {
+			return nil, fmt.Errorf("invalid AES-KDF seed")
+		}
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, err
+		}
+		key := append([]byte{}, composite...)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(key[0:16], key[0:16])
+			block.Encrypt(key[16:32], key[16:32])
+		}
+		res := sha256.Sum256(key)
+		return res[:], nil
+
+	case bytes.Equal(id, kdfArgon2d), bytes.Equal(id, kdfArgon2id):
+		iterations, _ := h.kdf["I"].(uint64)
+		memory, _ := h.kdf["M"].(uint64)
+		parallelism, _ := h.kdf["P"].(uint32)
+		secret, _ := h.kdf["K"].([]byte)
+		data, _ := h.kdf["A"].([]byte)
+		if memory/1024 > argon2MaxMemoryKiB || iterations > 1<<32-1 || parallelism > 255 {
+			return nil, fmt.Errorf("unsupported Argon2 parameters")
+		}
+
+		if bytes.Equal(id, kdfArgon2d) {
+			return argon2dKey(composite, salt, secret, data, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+		}
+		if len(secret) > 0 || len(data) > 0 {
+			return nil, fmt.Errorf("unsupported Argon2id secret key or associated data")
+		}
+		return argon2.IDKey(composite, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+	}
+
+	return nil, fmt.Errorf("unsupported KeePass key derivation function %x", id)
+}
+
+// decrypt decrypts the payload with the cipher of the header
+func (h *kdbxHeader) decrypt(key, payload []byte) ([]byte, error) {
+	if bytes.Equal(h.cipherID, kdbxChaCha) {
+		c, err := chacha20.NewUnauthenticatedCipher(key, h.iv)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, len(payload))
+		c.XORKeyStream(res, payload)
+		return res, nil
+	}
+
+	var block cipher.Block
+	var err error
+	switch {
+	case bytes.Equal(h.cipherID, kdbxAES256):
+		block, err = aes.NewCipher(key)
+	case bytes.Equal(h.cipherID, kdbxTwofish):
+		block, err = twofish.NewCipher(key)
+	default:
+		return nil, fmt.Errorf("unsupported KeePass cipher %x", h.cipherID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) == 0 || len(payload)%block.BlockSize() != 0 || len(h.iv) != block.BlockSize() {
+		return nil, errKDBXCredentials
+	}
+	res := make([]byte, len(payload))
+	cipher.NewCBCDecrypter(block, h.iv).CryptBlocks(res, payload)
+
+	// remove the PKCS#7 padding
+	pad := int(res[len(res)-1])
+	if pad == 0 || pad > block.BlockSize() || pad > len(res) {
+		return nil, errKDBXCredentials
+	}
+	for _, b := range res[len(res)-pad:] {
+		if int(b) != pad {
+			return nil, errKDBXCredentials
+		}
+	}
+	return res[:len(res)-pad], nil
+}
+
+// readInnerHeader reads the KDBX 4 inner header returning the XML document that follows it
+func (h *kdbxHeader) readInnerHeader(data []byte) ([]byte, error) {
+	rd := bytes.NewReader(data)
+	for {
+		id, err := rd.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("invalid KeePass inner header")
+		}
+		var size uint32
+		if err := binary.Read(rd, binary.LittleEndian, &size); err != nil || int(size) > rd.Len() {
+			return nil, fmt.Errorf("invalid KeePass inner header")
+		}
+		val := make([]byte, size)
+		rd.Read(val)
+
+		switch id {
+		case kdbxInnerEnd:
+			return data[len(data)-rd.Len():], nil
+		case kdbxInnerStreamID:
+			if len(val) >= 4 {
+				h.streamID = binary.LittleEndian.Uint32(val)
+			}
+		case kdbxInnerKey:
+			h.streamKey = val
+		}
+	}
+}
+
+// readKDBX4Payload verifies the header and the HMAC blocks returning the encrypted payload
+func readKDBX4Payload(header, data, hmacBase []byte) ([]byte, error) {
+	if len(data) < 64 {
+		return nil, fmt.Errorf("invalid KeePass header")
+	}
+	sum := sha256.Sum256(header)
+	if !bytes.Equal(sum[:], data[:32]) {
+		return nil, fmt.Errorf("corrupted KeePass header")
+	}
+	if !hmac.Equal(blockHMAC(hmacBase, ^uint64(0), header), data[32:64]) {
+		return nil, errKDBXCredentials
+	}
+
+	var res bytes.Buffer
+	rd := bytes.NewReader(data[64:])
+	for idx := uint64(0); ; idx++ {
+		mac := make([]byte, 32)
+		var size int32
+		if _, err := io.ReadFull(rd, mac); err != nil {
+			return nil, fmt.Errorf("truncated KeePass database")
+		}
+		if err := binary.Read(rd, binary.LittleEndian, &size); err != nil || size < 0 || int(size) > rd.Len() {
+			return nil, fmt.Errorf("truncated KeePass database")
+		}
+		block := make([]byte, size)
+		rd.Read(block)
+
+		var prefix [12]byte
+		binary.LittleEndian.PutUint64(prefix[:8], idx)
+		binary.LittleEndian.PutUint32(prefix[8:], uint32(size))
+		if !hmac.Equal(blockHMAC(hmacBase, idx, append(prefix[:], block...)), mac) {
+			return nil, fmt.Errorf("corrupted KeePass database block %d", idx)
+		}
+
+		if size == 0 {
+			return res.Bytes(), nil
+		}
+		res.Write(block)
+	}
+}
+
+func blockHMAC(hmacBase []byte, idx uint64, data []byte) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], idx)
+	key := sha512.Sum512(append(b[:], hmacBase...))
+
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// readHashedBlocks reads the KDBX 3 hashed block stream
+func readHashedBlocks(data []byte) ([]byte, error) {
+	var res bytes.Buffer
+	rd := bytes.NewReader(data)
+	for {
+		var idx, size uint32
+		hash := make([]byte, 32)
+		if err := binary.Read(rd, binary.LittleEndian, &idx); err != nil {
+			return nil, fmt.Errorf("truncated KeePass database")
+		}
+		if _, err := io.ReadFull(rd, hash); err != nil {
+			return nil, fmt.Errorf("truncated KeePass database")
+		}
+		if err := binary.Read(rd, binary.LittleEndian, &size); err != nil || int(size) > rd.Len() {
+			return nil, fmt.Errorf("truncated KeePass database")
+		}
+		if size == 0 {
+			return res.Bytes(), nil
+		}
+
+		block := make([]byte, size)
+		rd.Read(block)
+		if sum := sha256.Sum256(block); !bytes.Equal(sum[:], hash) {
+			return nil, fmt.Errorf("corrupted KeePass database block %d", idx)
+		}
+		res.Write(block)
+	}
+}
+
+// readVariantDictionary reads the KDBX 4 KDF parameters
+func readVariantDictionary(data []byte) (map[string]interface{}, error) {
+	errInvalid := fmt.Errorf("invalid KeePass KDF parameters")
+	if len(data) < 2 || data[1] != 1 {
+		return nil, errInvalid
+	}
+
+	res := make(map[string]interface{})
+	rd := bytes.NewReader(data[2:])
+	for {
+		kind, err := rd.ReadByte()
+		if err != nil {
+			return nil, errInvalid
+		}
+		if kind == 0 {
+			return res, nil
+		}
+
+		var keyLen, valLen int32
+		if err := binary.Read(rd, binary.LittleEndian, &keyLen); err != nil || keyLen < 0 || int(keyLen) > rd.Len() {
+			return nil, errInvalid
+		}
+		key := make([]byte, keyLen)
+		rd.Read(key)
+		if err := binary.Read(rd, binary.LittleEndian, &valLen); err != nil || valLen < 0 || int(valLen) > rd.Len() {
+			return nil, errInvalid
+		}
+		val := make([]byte, valLen)
+		rd.Read(val)
+
+		switch {
+		case kind == 0x04 && valLen == 4:
+			res[string(key)] = binary.LittleEndian.Uint32(val)
+		case kind == 0x05 && valLen == 8:
+			res[string(key)] = binary.LittleEndian.Uint64(val)
+		case kind == 0x08 && valLen == 1:
+			res[string(key)] = val[0] != 0
+		case kind == 0x0C && valLen == 4:
+			res[string(key)] = int32(binary.LittleEndian.Uint32(val))
+		case kind == 0x0D && valLen == 8:
+			res[string(key)] = int64(binary.LittleEndian.Uint64(val))
+		case kind == 0x18:
+			res[string(key)] = string(val)
+		case kind == 0x42:
+			res[string(key)] = val
+		default:
+			return nil, errInvalid
+		}
+	}
+}
+
+// compositeKey returns the KeePass composite key of the password and the key file
+func compositeKey(password string, keyFile []byte) ([]byte, error) {
+	var parts []byte
+	if password != "" || keyFile == nil {
+		sum := sha256.Sum256([]byte(password))
+		parts = append(parts, sum[:]...)
+	}
+
+	if keyFile != nil {
+		key, err := keyFileKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, key...)
+	}
+
+	res := sha256.Sum256(parts)
+	return res[:], nil
+}
+
+var hex64Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// keyFileKey returns the key of a KeePass key file (XML, 32 bytes binary, 64 hex chars or any other file)
+func keyFileKey(data []byte) ([]byte, error) {
+	var doc struct {
+		XMLName xml.Name `xml:"KeyFile"`
+		Version string   `xml:"Meta>Version"`
+		Data    struct {
+			Hash  string `xml:"Hash,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Key>Data"`
+	}
+	if err := xml.Unmarshal(data, &doc); err == nil {
+		value := strings.Join(strings.Fields(doc.Data.Value), "")
+		if strings.HasPrefix(doc.Version, "2.") {
+			key, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid KeePass key file - %s", err.Error())
+			}
+			if sum := sha256.Sum256(key); doc.Data.Hash != "" && !strings.EqualFold(hex.EncodeToString(sum[:4]), doc.Data.Hash) {
+				return nil, fmt.Errorf("invalid KeePass key file, hash mismatch")
+			}
+			return key, nil
+		}
+		return base64.StdEncoding.DecodeString(value)
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if hex64Regexp.Match(data) {
+		return hex.DecodeString(string(data))
+	}
+
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// kdbxStream is the inner random stream protecting the values in the XML document
+type kdbxStream struct {
+	chacha  *chacha20.Cipher
+	key     [32]byte
+	counter [16]byte
+	block   [64]byte
+	pos     int
+}
+
+func newKDBXStream(id uint32, key []byte) (*kdbxStream, error) {
+	switch id {
+	case kdbxSalsa20:
+		s := &kdbxStream{key: sha256.Sum256(key), pos: 64}
+		copy(s.counter[:8], salsa20Nonce)
+		return s, nil
+	case kdbxChaCha20:
+		h := sha512.Sum512(key)
+		c, err := chacha20.NewUnauthenticatedCipher(h[:32], h[32:44])
+		if err != nil {
+			return nil, err
+		}
+		return &kdbxStream{chacha: c}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported KeePass inner stream %d", id)
+}
+
+// unprotect decodes and decrypts a protected value, the values must be processed in the document order
+func (s *kdbxStream) unprotect(val string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val))
+	if err != nil {
+		return "", err
+	}
+
+	if s.chacha != nil {
+		s.chacha.XORKeyStream(data, data)
+		return string(data), nil
+	}
+
+	for i := range data {
+		if s.pos == 64 {
+			var zero [64]byte
+			salsa.XORKeyStream(s.block[:], zero[:], &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.pos = 0
+		}
+		data[i] ^= s.block[s.pos]
+		s.pos++
+	}
+	return string(data), nil
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
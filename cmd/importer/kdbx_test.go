@@ -0,0 +1,360 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// the fixtures are small databases built following the KDBX specification, with one entry
+// in the root group, one in a sub group and one in the recycle bin
+
+var (
+	fixtureCreated  = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fixtureReplaced = time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	fixtureModified = time.Date(2022, 11, 12, 13, 14, 15, 0, time.UTC)
+
+	fixtureMailUUID = bytes.Repeat([]byte{0x11}, 16)
+	fixtureBinUUID  = bytes.Repeat([]byte{0x22}, 16)
+)
+
+func TestReadKDBX3(t *testing.T) {
+	data := kdbx3Fixture(t, "s3cr3t")
+
+	records, err := kdbxRecords(data, "s3cr3t", nil)
+	assert.Nil(t, err)
+	assertFixtureRecords(t, records)
+
+	_, err = kdbxRecords(data, "wrong", nil)
+	assert.Equal(t, errKDBXCredentials, err)
+}
+
+func TestReadKDBX4(t *testing.T) {
+	data := kdbx4Fixture(t, "s3cr3t", nil)
+
+	records, err := kdbxRecords(data, "s3cr3t", nil)
+	assert.Nil(t, err)
+	assertFixtureRecords(t, records)
+
+	_, err = kdbxRecords(data, "wrong", nil)
+	assert.Equal(t, errKDBXCredentials, err)
+
+	// the header is authenticated, this is a byte of the cipher ID
+	data[20] ^= 0xFF
+	_, err = kdbxRecords(data, "s3cr3t", nil)
+	assert.NotNil(t, err)
+}
+
+func TestReadKDBX4KeyFile(t *testing.T) {
+	key := bytes.Repeat([]byte{0xA5}, 32)
+	sum := sha256.Sum256(key)
+	keyFile := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key><Data Hash="%X">%X</Data></Key>
+</KeyFile>`, sum[:4], key)
+
+	data := kdbx4Fixture(t, "s3cr3t", key)
+
+	records, err := kdbxRecords(data, "s3cr3t", []byte(keyFile))
+	assert.Nil(t, err)
+	assertFixtureRecords(t, records)
+
+	_, err = kdbxRecords(data, "s3cr3t", nil)
+	assert.Equal(t, errKDBXCredentials, err)
+}
+
+func assertFixtureRecords(t *testing.T, records []pwsafe.Record) {
+	if !assert.Equal(t, 2, len(records)) {
+		return
+	}
+
+	mail := records[0]
+	assert.Equal(t, "mail", mail.Title)
+	assert.Equal(t, "", mail.Group)
+	assert.Equal(t, "me", mail.Username)
+	assert.Equal(t, "new <pass> & more", mail.Password)
+	assert.Equal(t, "https://mail.example.com", mail.URL)
+	assert.Equal(t, "personal\n\nPIN: 1234\nTags: home", mail.Notes)
+	assert.Equal(t, fixtureMailUUID, mail.UUID[:])
+	assert.Equal(t, fixtureCreated.Unix(), mail.CreateTime.Unix())
+	assert.Equal(t, fixtureModified.Unix(), mail.ModTime.Unix())
+	assert.Equal(t, fixtureReplaced.Unix(), mail.PasswordModified().Unix())
+
+	history := mail.PasswordHistoryEntries()
+	if assert.Equal(t, 1, len(history)) {
+		assert.Equal(t, "old pass", history[0].Password)
+		assert.Equal(t, fixtureReplaced.Unix(), history[0].Time.Unix())
+	}
+
+	vpn := records[1]
+	assert.Equal(t, "vpn", vpn.Title)
+	assert.Equal(t, "Work.Remote", vpn.Group)
+	assert.Equal(t, "vpn-pass", vpn.Password)
+	assert.Equal(t, []byte("12345678901234567890"), vpn.TwoFactorKey)
+	assert.Equal(t, "", vpn.Notes)
+}
+
+// kdbx3Fixture returns a KDBX 3.1 database: AES-KDF, AES-256, gzip and Salsa20 inner stream
+func kdbx3Fixture(t *testing.T, password string) []byte {
+	masterSeed := bytes.Repeat([]byte{0x01}, 32)
+	transformSeed := bytes.Repeat([]byte{0x02}, 32)
+	iv := bytes.Repeat([]byte{0x03}, 16)
+	streamKey := bytes.Repeat([]byte{0x04}, 32)
+	streamStart := bytes.Repeat([]byte{0x05}, 32)
+	const rounds = 100
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, []uint32{kdbxSignature1, kdbxSignature2, 3<<16 | 1})
+	field := func(id byte, val []byte) {
+		header.WriteByte(id)
+		binary.Write(&header, binary.LittleEndian, uint16(len(val)))
+		header.Write(val)
+	}
+	field(kdbxCipherID, kdbxAES256)
+	field(kdbxCompression, le32(1))
+	field(kdbxMasterSeed, masterSeed)
+	field(kdbxTransformSd, transformSeed)
+	field(kdbxTransformRd, le64(rounds))
+	field(kdbxEncryptionIV, iv)
+	field(kdbxStreamKey, streamKey)
+	field(kdbxStreamStart, streamStart)
+	field(kdbxStreamID, le32(kdbxSalsa20))
+	field(kdbxEndOfHeader, []byte("\r\n\r\n"))
+
+	// AES-KDF
+	composite := sha256.Sum256(sha256Bytes([]byte(password)))
+	block, _ := aes.NewCipher(transformSeed)
+	key := composite[:]
+	for i := 0; i < rounds; i++ {
+		block.Encrypt(key[0:16], key[0:16])
+		block.Encrypt(key[16:32], key[16:32])
+	}
+	transformed := sha256.Sum256(key)
+	masterKey := sha256.Sum256(append(append([]byte{}, masterSeed...), transformed[:]...))
+
+	doc := fixtureXML(t, kdbxSalsa20, streamKey, func(t time.Time) string { return t.Format(time.RFC3339) })
+
+	// the hashed block stream of the compressed document
+	var plain bytes.Buffer
+	plain.Write(streamStart)
+	content := gzipBytes(doc)
+	for idx, chunk := range [][]byte{content[:len(content)/2], content[len(content)/2:], nil} {
+		binary.Write(&plain, binary.LittleEndian, uint32(idx))
+		if chunk == nil {
+			plain.Write(make([]byte, 32))
+		} else {
+			plain.Write(sha256Bytes(chunk))
+		}
+		binary.Write(&plain, binary.LittleEndian, uint32(len(chunk)))
+		plain.Write(chunk)
+	}
+
+	// AES-256-CBC with PKCS#7 padding
+	block, _ = aes.NewCipher(masterKey[:])
+	pad := aes.BlockSize - plain.Len()%aes.BlockSize
+	plain.Write(bytes.Repeat([]byte{byte(pad)}, pad))
+	payload := make([]byte, plain.Len())
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(payload, plain.Bytes())
+
+	return append(header.Bytes(), payload...)
+}
+
+// kdbx4Fixture returns a KDBX 4 database: Argon2d, ChaCha20, gzip and ChaCha20 inner stream,
+// with the key of the key file if not nil
+func kdbx4Fixture(t *testing.T, password string, fileKey []byte) []byte {
+	masterSeed := bytes.Repeat([]byte{0x06}, 32)
+	salt := bytes.Repeat([]byte{0x07}, 32)
+	iv := bytes.Repeat([]byte{0x08}, 12)
+	streamKey := bytes.Repeat([]byte{0x09}, 64)
+
+	var kdf bytes.Buffer
+	kdf.Write([]byte{0x00, 0x01})
+	param := func(kind byte, name string, val []byte) {
+		kdf.WriteByte(kind)
+		binary.Write(&kdf, binary.LittleEndian, int32(len(name)))
+		kdf.WriteString(name)
+		binary.Write(&kdf, binary.LittleEndian, int32(len(val)))
+		kdf.Write(val)
+	}
+	param(0x42, "$UUID", kdfArgon2d)
+	param(0x05, "I", le64(2))
+	param(0x05, "M", le64(64*1024))
+	param(0x04, "P", le32(2))
+	param(0x04, "V", le32(0x13))
+	param(0x42, "S", salt)
+	kdf.WriteByte(0)
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, []uint32{kdbxSignature1, kdbxSignature2, 4 << 16})
+	field := func(id byte, val []byte) {
+		header.WriteByte(id)
+		binary.Write(&header, binary.LittleEndian, uint32(len(val)))
+		header.Write(val)
+	}
+	field(kdbxCipherID, kdbxChaCha)
+	field(kdbxCompression, le32(1))
+	field(kdbxMasterSeed, masterSeed)
+	field(kdbxEncryptionIV, iv)
+	field(kdbxKdfParams, kdf.Bytes())
+	field(kdbxEndOfHeader, []byte("\r\n\r\n"))
+
+	parts := sha256Bytes([]byte(password))
+	if fileKey != nil {
+		parts = append(parts, fileKey...)
+	}
+	transformed := argon2dKey(sha256Bytes(parts), salt, nil, nil, 2, 64, 2, 32)
+	masterKey := sha256.Sum256(append(append([]byte{}, masterSeed...), transformed...))
+	hmacBase := sha512.Sum512(append(append(append([]byte{}, masterSeed...), transformed...), 1))
+
+	// the inner header followed by the document, compressed and encrypted
+	var inner bytes.Buffer
+	innerField := func(id byte, val []byte) {
+		inner.WriteByte(id)
+		binary.Write(&inner, binary.LittleEndian, uint32(len(val)))
+		inner.Write(val)
+	}
+	innerField(kdbxInnerStreamID, le32(kdbxChaCha20))
+	innerField(kdbxInnerKey, streamKey)
+	innerField(kdbxInnerEnd, nil)
+	inner.Write(fixtureXML(t, kdbxChaCha20, streamKey, func(t time.Time) string {
+		return base64.StdEncoding.EncodeToString(le64(uint64(t.Unix() + kdbxEpoch)))
+	}))
+
+	content := gzipBytes(inner.Bytes())
+	c, _ := chacha20.NewUnauthenticatedCipher(masterKey[:], iv)
+	c.XORKeyStream(content, content)
+
+	// the header hash and HMAC followed by the HMAC blocks
+	res := append([]byte{}, header.Bytes()...)
+	res = append(res, sha256Bytes(header.Bytes())...)
+	res = append(res, blockHMAC(hmacBase[:], ^uint64(0), header.Bytes())...)
+	for idx, chunk := range [][]byte{content[:len(content)/2], content[len(content)/2:], {}} {
+		var prefix [12]byte
+		binary.LittleEndian.PutUint64(prefix[:8], uint64(idx))
+		binary.LittleEndian.PutUint32(prefix[8:], uint32(len(chunk)))
+		res = append(res, blockHMAC(hmacBase[:], uint64(idx), append(prefix[:], chunk...))...)
+		res = append(res, prefix[8:]...)
+		res = append(res, chunk...)
+	}
+
+	return res
+}
+
+// fixtureXML returns the document of the fixtures, the protected values are encrypted in the document order
+func fixtureXML(t *testing.T, streamID uint32, streamKey []byte, timeFormat func(time.Time) string) []byte {
+	stream, err := newKDBXStream(streamID, streamKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protect := func(val string) string {
+		res, err := stream.unprotect(base64.StdEncoding.EncodeToString([]byte(val)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString([]byte(res))
+	}
+	b64 := base64.StdEncoding.EncodeToString
+
+	var doc strings.Builder
+	doc.WriteString(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>` + b64(fixtureBinUUID) + `</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>` + b64(bytes.Repeat([]byte{0x33}, 16)) + `</UUID>
+			<Name>Database</Name>
+			<Entry>
+				<UUID>` + b64(fixtureMailUUID) + `</UUID>
+				<Tags>home</Tags>
+				<Times>
+					<CreationTime>` + timeFormat(fixtureCreated) + `</CreationTime>
+					<LastModificationTime>` + timeFormat(fixtureModified) + `</LastModificationTime>
+					<Expires>False</Expires>
+				</Times>
+				<String><Key>Title</Key><Value>mail</Value></String>
+				<String><Key>UserName</Key><Value>me</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + protect("new <pass> & more") + `</Value></String>
+				<String><Key>URL</Key><Value>https://mail.example.com</Value></String>
+				<String><Key>Notes</Key><Value>personal</Value></String>
+				<String><Key>PIN</Key><Value Protected="True">` + protect("1234") + `</Value></String>
+				<History>
+					<Entry>
+						<Times><LastModificationTime>` + timeFormat(fixtureCreated) + `</LastModificationTime></Times>
+						<String><Key>Title</Key><Value>mail</Value></String>
+						<String><Key>Password</Key><Value Protected="True">` + protect("old pass") + `</Value></String>
+					</Entry>
+					<Entry>
+						<Times><LastModificationTime>` + timeFormat(fixtureReplaced) + `</LastModificationTime></Times>
+						<String><Key>Title</Key><Value>mail</Value></String>
+						<String><Key>Password</Key><Value Protected="True">` + protect("new <pass> & more") + `</Value></String>
+					</Entry>
+				</History>
+			</Entry>
+			<Group>
+				<Name>Work</Name>
+				<Group>
+					<Name>Remote</Name>
+					<Entry>
+						<String><Key>Title</Key><Value>vpn</Value></String>
+						<String><Key>Password</Key><Value Protected="True">` + protect("vpn-pass") + `</Value></String>
+						<String><Key>otp</Key><Value>otpauth://totp/vpn?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ</Value></String>
+					</Entry>
+				</Group>
+			</Group>
+			<Group>
+				<UUID>` + b64(fixtureBinUUID) + `</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>deleted</Value></String>
+					<String><Key>Password</Key><Value Protected="True">` + protect("gone") + `</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>
+`)
+	return []byte(doc.String())
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sha256Bytes(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
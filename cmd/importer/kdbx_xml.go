@@ -0,0 +1,279 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// xmlNode is a generic XML element, the children are kept in the document order
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+// the standard entry fields, the others are custom fields
+var kdbxStandardFields = map[string]bool{
+	"Title": true, "UserName": true, "Password": true, "URL": true, "Notes": true,
+}
+
+// kdbxEpoch is the number of seconds between 0001-01-01 and the unix epoch
+const kdbxEpoch = 62135596800
+
+// readKDBX reads the records from a KeePass KDBX 3.1 or 4 database
+func readKDBX(fn string, opts options) (*source, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyFile []byte
+	if opts.keyFile != "" {
+		if keyFile, err = ioutil.ReadFile(opts.keyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	prompt := "KeePass password: "
+	if keyFile != nil {
+		prompt = "KeePass password (empty for key file only): "
+	}
+	fmt.Print(prompt)
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println("")
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := kdbxRecords(data, string(password), keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source{records: records}, nil
+}
+
+// kdbxRecords decrypts the database returning the records of its entries, the recycle bin excluded
+func kdbxRecords(data []byte, password string, keyFile []byte) ([]pwsafe.Record, error) {
+	doc, stream, err := decryptKDBX(data, password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var root xmlNode
+	if err := xml.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("invalid KeePass XML document - %s", err.Error())
+	}
+	if err := root.unprotect(stream); err != nil {
+		return nil, err
+	}
+
+	recycleBin := ""
+	if meta := root.child("Meta"); meta != nil && meta.text("RecycleBinEnabled") == "True" {
+		recycleBin = meta.text("RecycleBinUUID")
+	}
+
+	var res []pwsafe.Record
+	if top := root.child("Root"); top != nil {
+		for _, g := range top.children("Group") {
+			// the names of the top level groups are the database names
+			res = append(res, kdbxGroupRecords(g, nil, recycleBin)...)
+		}
+	}
+
+	return res, nil
+}
+
+// kdbxGroupRecords returns the records of the entries in the group and its sub groups
+func kdbxGroupRecords(g *xmlNode, path []string, recycleBin string) []pwsafe.Record {
+	if recycleBin != "" && g.text("UUID") == recycleBin {
+		return nil
+	}
+
+	var res []pwsafe.Record
+	group := pwsafe.JoinGroup(path...)
+	for _, e := range g.children("Entry") {
+		res = append(res, kdbxEntryRecord(e, group))
+	}
+
+	for _, sub := range g.children("Group") {
+		res = append(res, kdbxGroupRecords(sub, append(path[:len(path):len(path)], sub.text("Name")), recycleBin)...)
+	}
+
+	return res
+}
+
+// kdbxEntryRecord maps an entry to a record, the custom fields are added to the notes
+func kdbxEntryRecord(e *xmlNode, group string) pwsafe.Record {
+	fields := e.strings()
+	rec := pwsafe.Record{
+		Title:    strings.TrimSpace(fields["Title"]),
+		Group:    group,
+		Username: strings.TrimSpace(fields["UserName"]),
+		Password: fields["Password"],
+		URL:      strings.TrimSpace(fields["URL"]),
+		Notes:    fields["Notes"],
+	}
+
+	if id, err := base64.StdEncoding.DecodeString(e.text("UUID")); err == nil && len(id) == 16 {
+		copy(rec.UUID[:], id)
+	}
+
+	var custom []string
+	for k := range fields {
+		if !kdbxStandardFields[k] {
+			custom = append(custom, k)
+		}
+	}
+	sort.Strings(custom)
+
+	var extra []string
+	for _, k := range custom {
+		switch k {
+		case "otp", "TimeOtp-Secret-Base32":
+			if err := setOTP(&rec, fields[k]); err == nil {
+				continue
+			}
+		}
+		extra = append(extra, fmt.Sprintf("%s: %s", k, fields[k]))
+	}
+	if tags := e.text("Tags"); tags != "" {
+		extra = append(extra, fmt.Sprintf("Tags: %s", tags))
+	}
+	addNotes(&rec, extra)
+
+	if times := e.child("Times"); times != nil {
+		rec.CreateTime = kdbxTime(times.text("CreationTime"))
+		rec.ModTime = kdbxTime(times.text("LastModificationTime"))
+		rec.AccessTime = kdbxTime(times.text("LastAccessTime"))
+		if times.text("Expires") == "True" {
+			rec.PasswordExpiry = kdbxTime(times.text("ExpiryTime"))
+		}
+	}
+
+	kdbxHistory(e, &rec)
+	return rec
+}
+
+// kdbxHistory sets the password history from the previous versions of the entry
+func kdbxHistory(e *xmlNode, rec *pwsafe.Record) {
+	type version struct {
+		password string
+		modTime  time.Time
+	}
+
+	var versions []version
+	if h := e.child("History"); h != nil {
+		for _, old := range h.children("Entry") {
+			var modTime time.Time
+			if times := old.child("Times"); times != nil {
+				modTime = kdbxTime(times.text("LastModificationTime"))
+			}
+			versions = append(versions, version{password: old.strings()["Password"], modTime: modTime})
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].modTime.Before(versions[j].modTime)
+	})
+	versions = append(versions, version{password: rec.Password, modTime: rec.ModTime})
+
+	// a password is replaced when the next version has a different one
+	var entries []pwsafe.PasswordHistoryEntry
+	changed := rec.CreateTime
+	for i := 0; i < len(versions)-1; i++ {
+		if versions[i].password != versions[i+1].password {
+			changed = versions[i+1].modTime
+			if versions[i].password != "" {
+				entries = append(entries, pwsafe.PasswordHistoryEntry{Time: changed, Password: versions[i].password})
+			}
+		}
+	}
+
+	rec.SetPasswordHistory(entries)
+	if !changed.IsZero() {
+		rec.SetPasswordModified(changed)
+	}
+}
+
+// kdbxTime parses the KDBX 3 ISO 8601 times and the KDBX 4 base64 encoded seconds since 0001-01-01
+func kdbxTime(val string) time.Time {
+	if val == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t
+	}
+
+	b, err := base64.StdEncoding.DecodeString(val)
+	if err != nil || len(b) != 8 {
+		return time.Time{}
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint64(b))-kdbxEpoch, 0)
+}
+
+// unprotect decrypts the protected values in the document order
+func (n *xmlNode) unprotect(stream *kdbxStream) error {
+	for _, a := range n.Attrs {
+		if a.Name.Local == "Protected" && a.Value == "True" {
+			val, err := stream.unprotect(n.Content)
+			if err != nil {
+				return fmt.Errorf("invalid KeePass protected value - %s", err.Error())
+			}
+			n.Content = val
+		}
+	}
+
+	for i := range n.Nodes {
+		if err := n.Nodes[i].unprotect(stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// strings returns the key/value pairs of the entry String elements
+func (n *xmlNode) strings() map[string]string {
+	res := make(map[string]string)
+	for _, s := range n.children("String") {
+		res[s.text("Key")] = s.text("Value")
+	}
+	return res
+}
+
+func (n *xmlNode) child(name string) *xmlNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) children(name string) []*xmlNode {
+	var res []*xmlNode
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			res = append(res, &n.Nodes[i])
+		}
+	}
+	return res
+}
+
+func (n *xmlNode) text(name string) string {
+	if c := n.child(name); c != nil {
+		return c.Content
+	}
+	return ""
+}
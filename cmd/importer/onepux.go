@@ -0,0 +1,303 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// the 1Password item categories with a specific mapping
+const (
+	onePuxLogin      = "001"
+	onePuxCard       = "002"
+	onePuxSecureNote = "003"
+	onePuxIdentity   = "004"
+	onePuxPassword   = "005"
+	onePuxDocument   = "006"
+)
+
+// onePuxDataFile is the JSON document holding the items in the 1PUX archive
+const onePuxDataFile = "export.data"
+
+type onePuxExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePuxItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePuxItem struct {
+	CreatedAt    int64  `json:"createdAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Details      struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Sections   []struct {
+			Fields []onePuxField `json:"fields"`
+		} `json:"sections"`
+		PasswordHistory []struct {
+			Value string `json:"value"`
+			Time  int64  `json:"time"`
+		} `json:"passwordHistory"`
+		Password string `json:"password"`
+	} `json:"details"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+		Tags []string `json:"tags"`
+	} `json:"overview"`
+}
+
+// onePuxField is a section field, the value has a single key telling its kind
+type onePuxField struct {
+	Title string                     `json:"title"`
+	ID    string                     `json:"id"`
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+// the section fields used as username of the items other than logins
+var onePuxUsernameFields = map[string]bool{"username": true, "cardholder": true}
+
+// onePuxCardFields are the card fields mapped to the record credit card fields
+var onePuxCardFields = map[string]func(*pwsafe.Record) *string{
+	"ccnum":  func(r *pwsafe.Record) *string { return &r.CreditCardNumber },
+	"expiry": func(r *pwsafe.Record) *string { return &r.CreditCardExpiration },
+	"cvv":    func(r *pwsafe.Record) *string { return &r.CreditCardVerifValue },
+	"pin":    func(r *pwsafe.Record) *string { return &r.CreditCardPIN },
+}
+
+// readOnePux reads the records from a 1Password 1PUX export
+func readOnePux(fn string, opts options) (*source, error) {
+	zr, err := zip.OpenReader(fn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 1PUX archive - %s", err.Error())
+	}
+	defer zr.Close()
+
+	var data []byte
+	for _, f := range zr.File {
+		if f.Name != onePuxDataFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("invalid 1PUX archive - %s not found", onePuxDataFile)
+	}
+
+	var export onePuxExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid 1PUX archive - %s", err.Error())
+	}
+
+	res := &source{}
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			group := pwsafe.JoinGroup(vault.Attrs.Name)
+			for _, item := range vault.Items {
+				if item.State == "archived" {
+					res.unmapped = append(res.unmapped, fmt.Sprintf("'%s': archived, skipped", item.Overview.Title))
+					continue
+				}
+				rec, unmapped := onePuxRecord(item, group)
+				res.records = append(res.records, rec)
+				res.unmapped = append(res.unmapped, unmapped...)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// onePuxRecord maps an item to a record, returning what could not be mapped
+func onePuxRecord(item onePuxItem, group string) (pwsafe.Record, []string) {
+	rec := pwsafe.Record{
+		Title:    strings.TrimSpace(item.Overview.Title),
+		Group:    group,
+		URL:      strings.TrimSpace(item.Overview.URL),
+		Notes:    item.Details.NotesPlain,
+		Password: item.Details.Password,
+	}
+	if item.CreatedAt > 0 {
+		rec.CreateTime = time.Unix(item.CreatedAt, 0)
+	}
+	if item.UpdatedAt > 0 {
+		rec.ModTime = time.Unix(item.UpdatedAt, 0)
+	}
+
+	var (
+		extra    []string
+		unmapped []string
+	)
+	report := func(format string, args ...interface{}) {
+		unmapped = append(unmapped, fmt.Sprintf("'%s': ", rec.Title)+fmt.Sprintf(format, args...))
+	}
+
+	for _, f := range item.Details.LoginFields {
+		switch {
+		case f.Designation == "username" && rec.Username == "":
+			rec.Username = strings.TrimSpace(f.Value)
+		case f.Designation == "password" && rec.Password == "":
+			rec.Password = f.Value
+		case f.Value != "" && f.Designation == "":
+			extra = append(extra, fmt.Sprintf("%s: %s", f.Name, f.Value))
+		}
+	}
+
+	for _, u := range item.Overview.URLs {
+		if url := strings.TrimSpace(u.URL); url != "" && url != rec.URL {
+			if rec.URL == "" {
+				rec.URL = url
+			} else {
+				extra = append(extra, fmt.Sprintf("URL: %s", url))
+			}
+		}
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, f := range section.Fields {
+			kind, val := onePuxValue(f)
+			label := f.Title
+			if label == "" {
+				label = f.ID
+			}
+
+			switch {
+			case kind == "file":
+				report("attachment '%s' skipped", label)
+			case val == "":
+			case item.CategoryUUID == onePuxCard && onePuxCardFields[f.ID] != nil:
+				*onePuxCardFields[f.ID](&rec) = val
+			case kind == "totp" && !rec.HasOTP():
+				if err := setOTP(&rec, val); err != nil {
+					extra = append(extra, fmt.Sprintf("%s: %s", label, val))
+					report("invalid TOTP seed kept in the notes (%s)", err.Error())
+				}
+			case item.CategoryUUID != onePuxLogin && item.CategoryUUID != onePuxCard && rec.Password == "" && kind == "concealed":
+				rec.Password = val
+			case item.CategoryUUID != onePuxLogin && rec.Username == "" && onePuxUsernameFields[f.ID]:
+				rec.Username = val
+			default:
+				extra = append(extra, fmt.Sprintf("%s: %s", label, val))
+			}
+		}
+	}
+
+	if len(item.Overview.Tags) > 0 {
+		extra = append(extra, fmt.Sprintf("Tags: %s", strings.Join(item.Overview.Tags, ", ")))
+	}
+	addNotes(&rec, extra)
+
+	var history []pwsafe.PasswordHistoryEntry
+	for _, h := range item.Details.PasswordHistory {
+		history = append(history, pwsafe.PasswordHistoryEntry{Time: time.Unix(h.Time, 0), Password: h.Value})
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+	rec.SetPasswordHistory(history)
+	if len(history) > 0 {
+		rec.SetPasswordModified(history[len(history)-1].Time)
+	}
+
+	switch item.CategoryUUID {
+	case onePuxLogin, onePuxPassword:
+	case onePuxCard:
+		report("card, stored with the placeholder password '%s'", pwsafe.PlaceholderPassword)
+	case onePuxDocument:
+		report("document, the file is not imported")
+	default:
+		if rec.Password == "" {
+			report("no password field, stored with the placeholder password '%s'", pwsafe.PlaceholderPassword)
+		}
+	}
+	if rec.Password == "" && item.CategoryUUID != onePuxLogin {
+		rec.Password = pwsafe.PlaceholderPassword
+	}
+
+	return rec, unmapped
+}
+
+// onePuxValue returns the kind of the field value and the value as text
+func onePuxValue(f onePuxField) (string, string) {
+	for kind, raw := range f.Value {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return kind, strings.TrimSpace(s)
+		}
+
+		switch kind {
+		case "date":
+			var t int64
+			if json.Unmarshal(raw, &t) == nil && t != 0 {
+				return kind, time.Unix(t, 0).UTC().Format("2006-01-02")
+			}
+		case "monthYear":
+			var n int
+			if json.Unmarshal(raw, &n) == nil && n != 0 {
+				return kind, fmt.Sprintf("%02d/%04d", n%100, n/100)
+			}
+		case "email":
+			var e struct {
+				Address string `json:"email_address"`
+			}
+			if json.Unmarshal(raw, &e) == nil {
+				return kind, e.Address
+			}
+		case "address":
+			var a map[string]string
+			if json.Unmarshal(raw, &a) == nil {
+				var parts []string
+				for _, k := range []string{"street", "city", "state", "zip", "country"} {
+					if a[k] != "" {
+						parts = append(parts, a[k])
+					}
+				}
+				return kind, strings.Join(parts, ", ")
+			}
+		case "sshKey":
+			var k struct {
+				PrivateKey string `json:"privateKey"`
+			}
+			if json.Unmarshal(raw, &k) == nil {
+				return "concealed", k.PrivateKey
+			}
+		case "file":
+			return kind, ""
+		}
+
+		var n json.Number
+		if json.Unmarshal(raw, &n) == nil {
+			return kind, n.String()
+		}
+		return kind, string(raw)
+	}
+	return "", ""
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestOnePuxRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		want     pwsafe.Record
+		unmapped int
+	}{
+		{
+			name: "login",
+			item: `{"categoryUuid": "001",
+				"overview": {"title": "github", "url": "https://github.com",
+					"urls": [{"url": "https://github.com"}, {"url": "https://gist.github.com"}], "tags": ["dev", "web"]},
+				"details": {"notesPlain": "a note",
+					"loginFields": [{"value": "john", "designation": "username"}, {"value": "s3cr3t", "designation": "password"},
+						{"value": "on", "name": "remember"}],
+					"sections": [{"fields": [{"title": "one-time password", "value": {"totp": "JBSWY3DPEHPK3PXP"}},
+						{"title": "recovery", "value": {"concealed": "r3c0v3ry"}}]}]}}`,
+			want: pwsafe.Record{
+				Title: "github", Username: "john", Password: "s3cr3t", URL: "https://github.com",
+				Notes: "a note\n\nremember: on\nURL: https://gist.github.com\nrecovery: r3c0v3ry\nTags: dev, web",
+			},
+		},
+		{
+			name: "card",
+			item: `{"categoryUuid": "002", "overview": {"title": "visa"},
+				"details": {"sections": [{"fields": [{"id": "cardholder", "title": "cardholder name", "value": {"string": "John Doe"}},
+					{"id": "ccnum", "value": {"creditCardNumber": "4111111111111111"}},
+					{"id": "cvv", "value": {"concealed": "123"}},
+					{"id": "expiry", "value": {"monthYear": 203003}},
+					{"id": "pin", "value": {"concealed": "0000"}},
+					{"id": "bank", "title": "issuing bank", "value": {"string": "ACME"}}]}]}}`,
+			want: pwsafe.Record{
+				Title: "visa", Username: "John Doe", Password: pwsafe.PlaceholderPassword, Notes: "issuing bank: ACME",
+				CreditCardNumber: "4111111111111111", CreditCardExpiration: "03/2030", CreditCardVerifValue: "123", CreditCardPIN: "0000",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "secure note",
+			item: `{"categoryUuid": "003", "overview": {"title": "wifi"}, "details": {"notesPlain": "on the router"}}`,
+			want: pwsafe.Record{
+				Title: "wifi", Password: pwsafe.PlaceholderPassword, Notes: "on the router",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "identity",
+			item: `{"categoryUuid": "004", "overview": {"title": "me"},
+				"details": {"sections": [{"fields": [{"title": "first name", "value": {"string": "John"}},
+					{"title": "email", "value": {"email": {"email_address": "john@doe.com"}}},
+					{"title": "address", "value": {"address": {"street": "1 Main St", "city": "Springfield"}}}]}]}}`,
+			want: pwsafe.Record{
+				Title: "me", Password: pwsafe.PlaceholderPassword,
+				Notes: "first name: John\nemail: john@doe.com\naddress: 1 Main St, Springfield",
+			},
+			unmapped: 1,
+		},
+		{
+			name: "password",
+			item: `{"categoryUuid": "005", "overview": {"title": "router"}, "details": {"password": "adm1n"}}`,
+			want: pwsafe.Record{
+				Title: "router", Password: "adm1n",
+			},
+		},
+		{
+			name: "server",
+			item: `{"categoryUuid": "110", "overview": {"title": "db"},
+				"details": {"sections": [{"fields": [{"id": "username", "title": "username", "value": {"string": "root"}},
+					{"id": "password", "title": "password", "value": {"concealed": "t00r"}},
+					{"title": "manual", "value": {"file": {"fileName": "manual.pdf"}}}]}]}}`,
+			want: pwsafe.Record{
+				Title: "db", Username: "root", Password: "t00r",
+			},
+			unmapped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item onePuxItem
+			if err := json.Unmarshal([]byte(tt.item), &item); err != nil {
+				t.Fatal(err)
+			}
+
+			rec, unmapped := onePuxRecord(item, "")
+			assert.Equal(t, tt.want, mappedFields(rec))
+			assert.Len(t, unmapped, tt.unmapped)
+		})
+	}
+}
+
+func TestReadOnePux(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "export.1pux")
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(onePuxDataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write([]byte(`{"accounts": [{"vaults": [{"attrs": {"name": "Private"}, "items": [
+		{"categoryUuid": "001", "overview": {"title": "github"},
+			"details": {"loginFields": [{"value": "s3cr3t", "designation": "password"}],
+				"passwordHistory": [{"value": "old", "time": 1577934245}]}},
+		{"categoryUuid": "001", "state": "archived", "overview": {"title": "gone"}}
+	]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	src, err := readOnePux(fn, options{})
+	if assert.Nil(t, err) && assert.Len(t, src.records, 1) {
+		rec := src.records[0]
+		assert.Equal(t, "Private", rec.Group)
+		assert.Equal(t, "s3cr3t", rec.Password)
+		assert.Equal(t, []pwsafe.PasswordHistoryEntry{{Time: rec.PasswordModified(), Password: "old"}}, rec.PasswordHistoryEntries())
+		assert.Equal(t, []string{"'gone': archived, skipped"}, src.unmapped)
+	}
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+// the extensions trimmed from the entry file names
+var passExtensions = []string{".gpg", ".txt"}
+
+// readPass reads the records from a password-store (the 'pass' tool) directory of decrypted entries:
+// the directories are the groups, the file names the titles
+func readPass(dir string, opts options) (*source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", dir)
+	}
+
+	res := &source{}
+	children := make(map[string]int)
+	var dirs []string
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+
+		// .git, .gpg-id, .extensions and the like
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		children[filepath.Dir(path)]++
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if passEncrypted(info.Name(), data) {
+			res.unmapped = append(res.unmapped, fmt.Sprintf("'%s': still encrypted, decrypt the store first", rel))
+			return nil
+		}
+
+		rec := utils.ParsePassEntry(string(data))
+		rec.Title = passTitle(parts[len(parts)-1])
+		rec.Group = pwsafe.JoinGroup(parts[:len(parts)-1]...)
+		res.records = append(res.records, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the directories with nothing inside
+	for _, d := range dirs {
+		if children[d] == 0 {
+			rel, _ := filepath.Rel(dir, d)
+			res.emptyGroups = append(res.emptyGroups, pwsafe.JoinGroup(strings.Split(filepath.ToSlash(rel), "/")...))
+		}
+	}
+
+	return res, nil
+}
+
+// passEncrypted tells if the file is still an OpenPGP message, binary or ASCII armored
+func passEncrypted(name string, data []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP MESSAGE-----")) {
+		return true
+	}
+	return strings.HasSuffix(name, ".gpg") && len(data) > 0 && data[0]&0x80 != 0
+}
+
+// passTitle returns the file name without the entry extensions
+func passTitle(name string) string {
+	for _, ext := range passExtensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestReadPass(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"Web/Dev/github.gpg": "s3cr3t\nuser: john\n\nuser: x\n",
+		"router.txt":         "adm1n\n",
+		"bank":               "-----BEGIN PGP MESSAGE-----\n...\n-----END PGP MESSAGE-----\n",
+		".gpg-id":            "john@doe.com\n",
+		".git/config":        "[core]\n",
+	}
+	for name, content := range files {
+		fn := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "Empty"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := readPass(dir, options{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []pwsafe.Record{
+		{Title: "github", Group: "Web.Dev", Username: "john", Password: "s3cr3t", Notes: "user: x"},
+		{Title: "router", Password: "adm1n"},
+	}, src.records)
+	assert.Equal(t, []string{"Empty"}, src.emptyGroups)
+	assert.Equal(t, []string{"'bank': still encrypted, decrypt the store first"}, src.unmapped)
+
+	_, err = readPass(filepath.Join(dir, "router.txt"), options{})
+	assert.NotNil(t, err)
+}
+
+func TestPassTitle(t *testing.T) {
+	tests := map[string]string{
+		"github.gpg": "github",
+		"github.txt": "github",
+		"github":     "github",
+		".gpg":       ".gpg",
+		"v1.2":       "v1.2",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, passTitle(name), name)
+	}
+}
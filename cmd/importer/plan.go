@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lucasepe/tablewriter"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+// the actions taken for an imported record
+const (
+	actionAdd       = "add"
+	actionOverwrite = "overwrite"
+	actionRename    = "rename"
+	actionSkip      = "skip"
+)
+
+// importEntry is an imported record with the action to take
+type importEntry struct {
+	record pwsafe.Record
+	action string
+	reason string
+}
+
+// planImport tells what to do with each imported record
+func planImport(records []pwsafe.Record, db pwsafe.DB, conflict, group string) []importEntry {
+	// the titles and the contents already used, to detect duplicates in the imported file too
+	titles := make(map[string]bool)
+	contents := make(map[string]string)
+	for _, t := range db.List() {
+		rec, _ := db.GetRecord(t)
+		titles[strings.ToLower(t)] = true
+		if hasCredentials(rec) {
+			contents[contentKey(rec)] = t
+		}
+	}
+
+	planned := make(map[string]bool)
+	res := make([]importEntry, 0, len(records))
+	for _, rec := range records {
+		el := importEntry{record: rec, action: actionAdd}
+		if group != "" {
+			el.record.Group = joinGroups(group, rec.Group)
+		}
+		if el.record.Title == "" {
+			el.record.Title = titleFromURL(rec.URL)
+		}
+
+		switch {
+		case el.record.Title == "":
+			el.action, el.reason = actionSkip, "missing title"
+		case el.record.Password == "":
+			el.action, el.reason = actionSkip, "missing password"
+		case hasCredentials(el.record) && contents[contentKey(el.record)] != "":
+			el.action, el.reason = actionSkip, fmt.Sprintf("duplicate of '%s'", contents[contentKey(el.record)])
+		}
+		if el.action == actionSkip {
+			res = append(res, el)
+			continue
+		}
+
+		if titles[strings.ToLower(el.record.Title)] {
+			existing, _ := utils.FindRecord(el.record.Title, db)
+			switch {
+			case planned[strings.ToLower(el.record.Title)] || conflict == "rename":
+				// records with the same title in the imported file are always renamed
+				el.record.Title = availableTitle(el.record.Title, titles)
+				el.action, el.reason = actionRename, "title already exists"
+			case conflict == "overwrite":
+				el.record = overwrite(existing, el.record)
+				el.action = actionOverwrite
+			default:
+				el.action, el.reason = actionSkip, "title already exists"
+				res = append(res, el)
+				continue
+			}
+		}
+
+		titles[strings.ToLower(el.record.Title)] = true
+		planned[strings.ToLower(el.record.Title)] = true
+		if hasCredentials(el.record) {
+			contents[contentKey(el.record)] = el.record.Title
+		}
+		res = append(res, el)
+	}
+
+	return res
+}
+
+// overwrite returns the existing record updated with the imported fields,
+// so that its UUID, creation time and the fields not imported are kept
+func overwrite(existing, imported pwsafe.Record) pwsafe.Record {
+	res := existing
+	res.Group = imported.Group
+	res.Username = imported.Username
+	res.Password = imported.Password
+	res.URL = imported.URL
+	if imported.Notes != "" {
+		res.Notes = imported.Notes
+	}
+	if imported.Email != "" {
+		res.Email = imported.Email
+	}
+	if imported.HasOTP() {
+		res.TwoFactorKey = imported.TwoFactorKey
+		res.TOTPLength = imported.TOTPLength
+		res.TOTPTimeStep = imported.TOTPTimeStep
+		res.TOTPStartTime = imported.TOTPStartTime
+	}
+	return res
+}
+
+// contentKey identifies the records holding the same credentials
+func contentKey(rec pwsafe.Record) string {
+	return strings.Join([]string{
+		strings.ToLower(rec.Username),
+		strings.ToLower(strings.TrimSuffix(rec.URL, "/")),
+		rec.Password,
+	}, "\x00")
+}
+
+// hasCredentials tells if the record holds a real password, the records with the
+// placeholder one (i.e. secure notes) are not duplicates of each other
+func hasCredentials(rec pwsafe.Record) bool {
+	return rec.Password != pwsafe.PlaceholderPassword
+}
+
+// titleFromURL returns the host of the URL, used when the imported record has no title
+func titleFromURL(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return ""
+	}
+
+	u, err := utils.ParseLooseURL(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(raw)
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// availableTitle returns the title with a ' (n)' suffix not already used
+func availableTitle(title string, titles map[string]bool) string {
+	for i := 2; ; i++ {
+		res := fmt.Sprintf("%s (%d)", title, i)
+		if !titles[strings.ToLower(res)] {
+			return res
+		}
+	}
+}
+
+// joinGroups returns the group nested in the parent one
+func joinGroups(parent, group string) string {
+	if group == "" {
+		return parent
+	}
+	return parent + string(pwsafe.GroupSeparator) + group
+}
+
+// addNotes appends the lines to the record notes, separated by an empty line
+func addNotes(rec *pwsafe.Record, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	if rec.Notes != "" {
+		rec.Notes += "\n\n"
+	}
+	rec.Notes += strings.Join(lines, "\n")
+}
+
+// cardExpiration returns the card expiration as MM/YYYY, the empty string if unknown
+func cardExpiration(month, year string) string {
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 || year == "" {
+		var parts []string
+		for _, p := range []string{month, year} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		return strings.Join(parts, "/")
+	}
+	if len(year) == 2 {
+		year = "20" + year
+	}
+	return fmt.Sprintf("%02d/%s", m, year)
+}
+
+// setOTP sets the record two factor fields from an otpauth URI or a base32 secret
+func setOTP(rec *pwsafe.Record, otp string) error {
+	if strings.HasPrefix(strings.ToLower(otp), "otpauth://") {
+		return rec.SetOTPAuthURI(otp)
+	}
+
+	if _, err := pwsafe.DecodeOTPSecret(otp); err != nil {
+		return err
+	}
+	return rec.SetOTPAuthURI(fmt.Sprintf("otpauth://totp/%s?secret=%s", url.PathEscape(rec.Title), url.QueryEscape(otp)))
+}
+
+// dumpPlan renders the actions that would be taken
+func dumpPlan(entries []importEntry) string {
+	table := tablewriter.CreateTable()
+	table.Style = tablewriter.GhostStyle
+	table.AddHeaders("ACTION", "TITLE", "GROUP", "USERNAME", "URL", "NOTE")
+
+	for _, el := range entries {
+		table.AddRow(
+			el.action,
+			el.record.Title,
+			el.record.Group,
+			utils.TruncateText(el.record.Username, 41),
+			utils.TruncateText(el.record.URL, 41),
+			el.reason,
+		)
+	}
+
+	return table.Render()
+}
+
+// summary returns how many records have been added, overwritten, renamed and skipped
+func summary(entries []importEntry) string {
+	counts := make(map[string]int)
+	for _, el := range entries {
+		counts[el.action]++
+	}
+
+	return fmt.Sprintf("%d records read: %d added, %d overwritten, %d renamed, %d skipped",
+		len(entries), counts[actionAdd], counts[actionOverwrite], counts[actionRename], counts[actionSkip])
+}
+
+// unmappedReport lists what could not be mapped to the record fields, the empty string if nothing
+func unmappedReport(src *source) string {
+	if len(src.unmapped) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d notes about items not fully mapped:\n", len(src.unmapped)))
+	for _, el := range src.unmapped {
+		sb.WriteString(fmt.Sprintf(" * %s\n", el))
+	}
+	return sb.String()
+}
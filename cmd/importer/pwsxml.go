@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"os"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// readPWSXML reads the records, the empty groups and the named password policies
+// from a file exported by the Password Safe desktop client
+func readPWSXML(fn string, opts options) (*source, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, records, err := pwsafe.ReadXML(f)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := header.NamedPasswordPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	return &source{records: records, emptyGroups: header.EmptyGroups, policies: policies}, nil
+}
+
+// importHeader adds the empty groups and the named password policies read from the source,
+// the existing policies are replaced only in overwrite mode
+func importHeader(src *source, db pwsafe.DB, conflict, group string) (int, int, error) {
+	groups := 0
+	for _, g := range src.emptyGroups {
+		// the groups already there are fine as they are
+		if err := db.AddEmptyGroup(joinGroups(group, g)); err == nil {
+			groups++
+		}
+	}
+
+	existing, err := db.NamedPasswordPolicies()
+	if err != nil {
+		return groups, 0, err
+	}
+	names := make(map[string]bool)
+	for _, p := range existing {
+		names[p.Name] = true
+	}
+
+	policies := 0
+	for _, p := range src.policies {
+		if names[p.Name] && conflict != "overwrite" {
+			continue
+		}
+		if err := db.SetNamedPasswordPolicy(p); err != nil {
+			return groups, policies, err
+		}
+		policies++
+	}
+
+	return groups, policies, nil
+}
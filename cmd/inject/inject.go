@@ -0,0 +1,181 @@
+package inject
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type injectAction struct {
+	input    string
+	output   string
+	check    bool
+	filename string
+}
+
+const (
+	cmdName   = "inject"
+	shortDesc = "render a template resolving the secret references"
+	longDesc  = `Render a Go text/template resolving the secret references against the records.
+
+Usage: %s %s -i <template> [-o <output>] [-check]
+
+ * reference a field with {{ pwsafe "Group/Title" "field" }}
+ * accepted values for 'field' are: user, pass, url, notes, email, group, title
+ * if the field is omitted the password is used
+ * the output file is written with 0600 permissions, if omitted stdout is used
+ * with -check the template is only validated and all the missing references are reported
+`
+)
+
+// NewInjectCommand create a 'inject' cli command
+func NewInjectCommand(filename string) *cli.Command {
+	action := injectAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+	}
+
+	return cmd
+}
+
+func (r *injectAction) handler() error {
+	if strings.TrimSpace(r.input) == "" {
+		return utils.NewMissingParameterError("template", cmdName)
+	}
+
+	src, err := ioutil.ReadFile(r.input)
+	if err != nil {
+		return err
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	if r.check {
+		return checkTemplate(filepath.Base(r.input), string(src), db)
+	}
+
+	out, err := render(filepath.Base(r.input), string(src), db)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(r.output) == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	err = utils.WritePrivateFile(r.output, out)
+	if err == nil {
+		fmt.Printf("\U0001f44d template successfully rendered to '%s'\n", r.output)
+	}
+
+	return err
+}
+
+func (r *injectAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.input), "i", "", "the template file")
+		fs.StringVar(&(r.output), "o", "", "the output file (default stdout)")
+		fs.BoolVar(&(r.check), "check", false, "only check that all references exist")
+	}
+}
+
+// render executes the template failing on the first missing reference
+func render(name, src string, db pwsafe.DB) ([]byte, error) {
+	funcs := template.FuncMap{
+		"pwsafe": func(title string, field ...string) (string, error) {
+			return utils.ResolveField(title, fieldName(field), db)
+		},
+	}
+
+	tpl, err := template.New(name).Funcs(funcs).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// checkTemplate executes the template collecting all the unresolved references
+func checkTemplate(name, src string, db pwsafe.DB) error {
+	var missing []string
+	total := 0
+	funcs := template.FuncMap{
+		"pwsafe": func(title string, field ...string) string {
+			total++
+			if _, err := utils.ResolveField(title, fieldName(field), db); err != nil {
+				missing = append(missing, fmt.Sprintf("%s:%s (%s)", title, fieldName(field), err.Error()))
+			}
+			return ""
+		},
+	}
+
+	tpl, err := template.New(name).Funcs(funcs).Parse(src)
+	if err != nil {
+		return err
+	}
+
+	if err := tpl.Execute(ioutil.Discard, nil); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		for _, m := range missing {
+			fmt.Fprintf(os.Stderr, "  ❌ %s\n", m)
+		}
+		return fmt.Errorf("%d of %d references can not be resolved", len(missing), total)
+	}
+
+	fmt.Printf("\U0001f44d all %d references successfully resolved\n", total)
+	return nil
+}
+
+func fieldName(field []string) string {
+	if len(field) > 0 {
+		return field[0]
+	}
+	return "pass"
+}
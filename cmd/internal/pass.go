@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// the 'key: value' lines mapped to the record fields
+var passKeys = map[string]string{
+	"user": "user", "username": "user", "login": "user",
+	"url": "url", "website": "url",
+	"email": "email", "mail": "email",
+}
+
+// PassEntry returns the content of a password-store (the 'pass' tool) entry: the password
+// on the first line, then the fields as 'key: value' lines, the otpauth URI and, after an
+// empty line, the notes so that ParsePassEntry doesn't read a note like 'user: x' as a field
+func PassEntry(rec pwsafe.Record) string {
+	lines := []string{rec.Password}
+	if rec.Username != "" {
+		lines = append(lines, "user: "+rec.Username)
+	}
+	if rec.URL != "" {
+		lines = append(lines, "url: "+rec.URL)
+	}
+	if rec.Email != "" {
+		lines = append(lines, "email: "+rec.Email)
+	}
+	if uri := rec.OTPAuthURI(); uri != "" {
+		lines = append(lines, uri)
+	}
+	if rec.Notes != "" {
+		lines = append(lines, "", rec.Notes)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ParsePassEntry maps the entry content to a record: the first line is the password,
+// the 'user:', 'url:' and 'email:' lines and the otpauth URI are mapped to the fields
+// and the other lines become the notes, as do all the lines after the first empty one
+func ParsePassEntry(content string) pwsafe.Record {
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+
+	rec := pwsafe.Record{Password: lines[0]}
+	var notes []string
+	inNotes := false
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		// after the empty line a 'user: x' line is part of the notes, not the username
+		if trimmed == "" {
+			inNotes = true
+		}
+		if inNotes {
+			notes = append(notes, line)
+			continue
+		}
+
+		if strings.HasPrefix(strings.ToLower(trimmed), "otpauth://") && !rec.HasOTP() {
+			if err := rec.SetOTPAuthURI(trimmed); err == nil {
+				continue
+			}
+		}
+
+		if idx := strings.Index(trimmed, ":"); idx > 0 {
+			val := strings.TrimSpace(trimmed[idx+1:])
+			switch passKeys[strings.ToLower(trimmed[:idx])] {
+			case "user":
+				if rec.Username == "" {
+					rec.Username = val
+					continue
+				}
+			case "url":
+				if rec.URL == "" {
+					rec.URL = val
+					continue
+				}
+			case "email":
+				if rec.Email == "" {
+					rec.Email = val
+					continue
+				}
+			}
+		}
+		notes = append(notes, line)
+	}
+
+	rec.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	return rec
+}
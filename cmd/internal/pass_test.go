@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+func TestParsePassEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    pwsafe.Record
+	}{
+		{
+			name:    "password only",
+			content: "s3cr3t\n",
+			want:    pwsafe.Record{Password: "s3cr3t"},
+		},
+		{
+			name:    "fields",
+			content: "s3cr3t\nLogin: john\nwebsite: https://github.com\nmail: john@doe.com\n",
+			want:    pwsafe.Record{Password: "s3cr3t", Username: "john", URL: "https://github.com", Email: "john@doe.com"},
+		},
+		{
+			name:    "crlf and notes among the fields",
+			content: "s3cr3t\r\nuser: john\r\nrecovery code 1234\r\nuser: other\r\n",
+			want:    pwsafe.Record{Password: "s3cr3t", Username: "john", Notes: "recovery code 1234\nuser: other"},
+		},
+		{
+			name:    "notes after an empty line",
+			content: "s3cr3t\nurl: https://github.com\n\nuser: x\nurl: y\n",
+			want:    pwsafe.Record{Password: "s3cr3t", URL: "https://github.com", Notes: "user: x\nurl: y"},
+		},
+		{
+			name:    "no key",
+			content: "s3cr3t\n: john\nhttps://github.com\n",
+			want:    pwsafe.Record{Password: "s3cr3t", Notes: ": john\nhttps://github.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParsePassEntry(tt.content))
+		})
+	}
+}
+
+func TestParsePassEntryOTP(t *testing.T) {
+	rec := ParsePassEntry("s3cr3t\notpauth://totp/github?secret=JBSWY3DPEHPK3PXP\notpauth://totp/other?secret=JBSWY3DPEHPK3PXP\n")
+	assert.True(t, rec.HasOTP())
+	assert.Equal(t, "otpauth://totp/other?secret=JBSWY3DPEHPK3PXP", rec.Notes)
+}
+
+func TestPassEntryRoundTrip(t *testing.T) {
+	tests := []pwsafe.Record{
+		{Password: "s3cr3t"},
+		{Password: "s3cr3t", Username: "john", URL: "https://github.com", Email: "john@doe.com", Notes: "a note"},
+		// the note lines that look like fields stay in the notes
+		{Password: "s3cr3t", Notes: "user: x\nurl: https://example.com"},
+		{Password: "s3cr3t", Username: "john", Notes: "email: other@doe.com\n\nmore notes"},
+	}
+
+	for _, rec := range tests {
+		assert.Equal(t, rec, ParsePassEntry(PassEntry(rec)))
+	}
+
+	rec := pwsafe.Record{Password: "s3cr3t", Notes: "user: x"}
+	assert.Nil(t, rec.SetOTPAuthURI("otpauth://totp/github?secret=JBSWY3DPEHPK3PXP"))
+	res := ParsePassEntry(PassEntry(rec))
+	assert.Equal(t, rec.OTPAuthURI(), res.OTPAuthURI())
+	assert.Equal(t, "", res.Username)
+	assert.Equal(t, "user: x", res.Notes)
+}
+
+func TestPassEntry(t *testing.T) {
+	rec := pwsafe.Record{Password: "s3cr3t", Username: "john", Email: "john@doe.com", Notes: "a note"}
+	assert.Equal(t, "s3cr3t\nuser: john\nemail: john@doe.com\n\na note\n", PassEntry(rec))
+}
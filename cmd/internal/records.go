@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// FindRecord looks up a record by title (case insensitive).
+// The title can be qualified by its group as in "Group/Title".
+func FindRecord(title string, db pwsafe.DB) (pwsafe.Record, bool) {
+	for _, t := range db.List() {
+		if strings.EqualFold(title, t) {
+			return db.GetRecord(t)
+		}
+	}
+
+	idx := strings.LastIndex(title, "/")
+	if idx <= 0 {
+		return pwsafe.Record{}, false
+	}
+
+	group, name := title[:idx], title[idx+1:]
+	for _, t := range db.List() {
+		if !strings.EqualFold(name, t) {
+			continue
+		}
+		if rec, ok := db.GetRecord(t); ok && strings.EqualFold(group, rec.Group) {
+			return rec, true
+		}
+	}
+
+	return pwsafe.Record{}, false
+}
+
+// FieldContent returns the content of the named field of the record.
+func FieldContent(rec pwsafe.Record, field string) (string, error) {
+	val, err := rec.Field(field)
+	if err != nil {
+		return "", fmt.Errorf("unknown field '%s' - accepted values are: user, pass, url, notes, email, group, title, autotype, runcmd", field)
+	}
+
+	return val, nil
+}
+
+// ResolveSecretRef resolves a reference like "Title:field" against the db.
+// When the field is omitted the password is returned.
+func ResolveSecretRef(ref string, db pwsafe.DB) (string, error) {
+	title, field := SplitSecretRef(ref)
+	return ResolveField(title, field, db)
+}
+
+// SplitSecretRef returns the title and the field of a reference like "Title:field",
+// the field is the password when omitted or when the suffix is not a field name
+func SplitSecretRef(ref string) (string, string) {
+	return pwsafe.SplitReference(ref)
+}
+
+// ResolveField returns the content of the field of the record with the given title.
+func ResolveField(title, field string, db pwsafe.DB) (string, error) {
+	rec, ok := FindRecord(title, db)
+	if !ok {
+		return "", fmt.Errorf("record '%s' not found", title)
+	}
+
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return "", err
+	}
+
+	return FieldContent(rec, field)
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// RenderGroupTree draws the groups hierarchy, optionally with the record titles.
+// The filter, if not nil, tells which records to show.
+func RenderGroupTree(root *pwsafe.GroupNode, withRecords bool, filter func(title string) bool) string {
+	var sb strings.Builder
+	if root.Path == "" {
+		sb.WriteString(".\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("%s\n", root.Path))
+	}
+	renderNode(&sb, root, "", withRecords, filter)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderNode(sb *strings.Builder, node *pwsafe.GroupNode, indent string, withRecords bool, filter func(string) bool) {
+	var records []string
+	if withRecords {
+		for _, t := range node.Records {
+			if filter == nil || filter(t) {
+				records = append(records, t)
+			}
+		}
+	}
+
+	total := len(node.Groups) + len(records)
+	i := 0
+	for _, child := range node.Groups {
+		i++
+		branch, next := "├── ", "│   "
+		if i == total {
+			branch, next = "└── ", "    "
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s/\n", indent, branch, child.Name))
+		renderNode(sb, child, indent+next, withRecords, filter)
+	}
+
+	for _, t := range records {
+		i++
+		branch := "├── "
+		if i == total {
+			branch = "└── "
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s\n", indent, branch, t))
+	}
+}
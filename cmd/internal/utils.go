@@ -11,9 +11,12 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"golang.org/x/crypto/ssh/terminal"
@@ -37,6 +40,27 @@ func FileExist(fn string) (bool, error) {
 	return true, nil
 }
 
+// WritePrivateFile writes the data to a file readable only by the owner
+func WritePrivateFile(fn string, data []byte) error {
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	// enforce the permissions on an already existing file too
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
 // GetEncryptedSecretPhrase get secret phrase from an RSA (base64)encrypted string
 func GetEncryptedSecretPhrase(fn string) (string, error) {
 	base := filepath.Base(fn)
@@ -101,6 +125,89 @@ func GetSecretPhrase() (string, error) {
 	return string(passBytes), nil
 }
 
+// GetSecretPhraseFromTTY read a password entry from the controlling terminal.
+// Useful when stdin is used for other purposes (i.e. credential helpers protocols).
+func GetSecretPhraseFromTTY() (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("unable to open the terminal to ask for the secret phrase: %s", err.Error())
+	}
+	defer tty.Close()
+
+	var passBytes []byte
+	for len(passBytes) == 0 {
+		fmt.Fprint(tty, "Secret phrase: ")
+		passBytes, err = terminal.ReadPassword(int(tty.Fd()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(tty, "")
+	}
+
+	return string(passBytes), nil
+}
+
+// Confirm asks a yes/no question on the controlling terminal, so that it works
+// when the standard output is redirected too. Only an explicit yes confirms.
+func Confirm(question string) (bool, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false, fmt.Errorf("unable to open the terminal to ask for confirmation: %s", err.Error())
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// ParseLooseURL parse an URL that may lack the scheme (i.e. 'github.com/lucasepe').
+// When missing the 'https' scheme is assumed.
+func ParseLooseURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	u.Host = strings.ToLower(u.Host)
+
+	return u, nil
+}
+
+// ParseDays parse a duration expressed in days (i.e. '90d'), weeks ('2w') or years ('1y').
+// The Go durations (i.e. '36h') are accepted too.
+func ParseDays(val string) (time.Duration, error) {
+	val = strings.ToLower(strings.TrimSpace(val))
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour}
+	if len(val) > 1 {
+		if unit, ok := units[val[len(val)-1:]]; ok {
+			n, err := strconv.ParseUint(val[:len(val)-1], 10, 16)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration '%s'", val)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration '%s' - use i.e. 90d, 2w, 1y", val)
+	}
+	return d, nil
+}
+
 // GetSecretPhraseDoubleCheck read a password entry from terminal.
 // This routine ask for the password twice in order to be sure.
 func GetSecretPhraseDoubleCheck() (string, error) {
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/cli"
 
@@ -20,6 +21,7 @@ type listAction struct {
 	query       string
 	filename    string
 	withHeaders bool
+	tree        bool
 }
 
 const (
@@ -30,6 +32,10 @@ const (
 Usage: %s %s [pattern]
 
  * if a pattern is specified only records matching it will be listed
+ * with -tree the records are shown in the groups hierarchy
+ * aliases are marked with [alias], shortcuts with [shortcut]
+   and those whose base entry is missing with [dangling]
+ * records whose password has expired are marked with [expired]
 `
 )
 
@@ -74,6 +80,11 @@ func (r *listAction) handler() error {
 		return err
 	}
 
+	if r.tree {
+		fmt.Println(dumpTree(r.query, db))
+		return nil
+	}
+
 	str := dump(r.filename, r.query, r.withHeaders, db)
 	fmt.Println(str)
 
@@ -84,6 +95,7 @@ func (r *listAction) flagHandler(fn string) func(fs *flag.FlagSet) {
 	return func(fs *flag.FlagSet) {
 		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
 		fs.BoolVar(&(r.withHeaders), "headers", false, "print columns headers")
+		fs.BoolVar(&(r.tree), "tree", false, "show the records in the groups hierarchy")
 	}
 }
 
@@ -113,6 +125,7 @@ func dump(caption, query string, headers bool, db pwsafe.DB) string {
 		exp = regexp.MustCompile(fmt.Sprintf("(?i)%s", query))
 	}
 
+	now := time.Now()
 	for _, x := range titles {
 		dump := true
 		if rec, ok := db.GetRecord(x); ok {
@@ -121,8 +134,12 @@ func dump(caption, query string, headers bool, db pwsafe.DB) string {
 			}
 
 			if dump {
+				title := recordTitle(rec, db)
+				if rec.PasswordExpired(now) {
+					title = fmt.Sprintf("%s [expired]", title)
+				}
 				table.AddRow(
-					rec.Title,
+					title,
 					rec.Group,
 					utils.TruncateText(rec.Username, 41),
 					rec.URL,
@@ -133,3 +150,34 @@ func dump(caption, query string, headers bool, db pwsafe.DB) string {
 
 	return table.Render()
 }
+
+// dumpTree renders the groups hierarchy with the records matching the query
+func dumpTree(query string, db pwsafe.DB) string {
+	var filter func(string) bool
+	if strings.TrimSpace(query) != "" {
+		exp := regexp.MustCompile(fmt.Sprintf("(?i)%s", query))
+		filter = func(title string) bool {
+			rec, ok := db.GetRecord(title)
+			return ok && (exp.MatchString(rec.Title) || exp.MatchString(rec.Group))
+		}
+	}
+
+	return utils.RenderGroupTree(db.GroupTree(), true, filter)
+}
+
+// recordTitle returns the record title marking aliases and shortcuts
+func recordTitle(rec pwsafe.Record, db pwsafe.DB) string {
+	kind, _ := rec.EntryType()
+	if kind == pwsafe.NormalEntry {
+		return rec.Title
+	}
+
+	if _, err := db.ResolveRecord(rec); err != nil {
+		return fmt.Sprintf("%s [dangling]", rec.Title)
+	}
+
+	if kind == pwsafe.AliasEntry {
+		return fmt.Sprintf("%s [alias]", rec.Title)
+	}
+	return fmt.Sprintf("%s [shortcut]", rec.Title)
+}
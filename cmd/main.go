@@ -7,13 +7,29 @@ import (
 
 	"github.com/lucasepe/cli"
 	"github.com/lucasepe/homedir"
+	"github.com/lucasepe/pwsafe/cmd/audit"
 	"github.com/lucasepe/pwsafe/cmd/clip"
+	"github.com/lucasepe/pwsafe/cmd/cp"
 	"github.com/lucasepe/pwsafe/cmd/create"
+	"github.com/lucasepe/pwsafe/cmd/dockercredential"
+	"github.com/lucasepe/pwsafe/cmd/edit"
+	"github.com/lucasepe/pwsafe/cmd/exec"
+	"github.com/lucasepe/pwsafe/cmd/expiring"
+	"github.com/lucasepe/pwsafe/cmd/export"
+	"github.com/lucasepe/pwsafe/cmd/gitcredential"
+	"github.com/lucasepe/pwsafe/cmd/group"
+	"github.com/lucasepe/pwsafe/cmd/importer"
+	"github.com/lucasepe/pwsafe/cmd/inject"
 	"github.com/lucasepe/pwsafe/cmd/internal"
 	"github.com/lucasepe/pwsafe/cmd/list"
+	"github.com/lucasepe/pwsafe/cmd/mv"
+	"github.com/lucasepe/pwsafe/cmd/otp"
 	"github.com/lucasepe/pwsafe/cmd/pull"
 	"github.com/lucasepe/pwsafe/cmd/push"
 	"github.com/lucasepe/pwsafe/cmd/remove"
+	"github.com/lucasepe/pwsafe/cmd/serve"
+	"github.com/lucasepe/pwsafe/cmd/share"
+	"github.com/lucasepe/pwsafe/cmd/show"
 )
 
 const (
@@ -22,6 +38,14 @@ const (
 )
 
 func main() {
+	// the detached process clearing the clipboard after 'clip'
+	if os.Getenv(clip.HelperEnv) != "" {
+		if err := clip.RunHelper(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	workDir, err := createWorkdir(appDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s", err.Error())
@@ -39,6 +63,15 @@ func main() {
 
 	filename := filepath.Join(workDir, dbFilename)
 
+	// docker invokes the credential helper as 'docker-credential-pwsafe <operation>'
+	if binName == dockercredential.BinaryName {
+		if err := dockercredential.Run(filename, os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stdout, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	err = bin.RegisterCommand(list.NewListCommand(filename))
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -75,6 +108,102 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = bin.RegisterCommand(serve.NewServeCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(exec.NewExecCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(inject.NewInjectCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(gitcredential.NewGitCredentialCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(dockercredential.NewDockerCredentialCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(otp.NewOTPCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(group.NewGroupCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(mv.NewMvCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(cp.NewCpCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(show.NewShowCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(edit.NewEditCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(importer.NewImportCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(export.NewExportCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(share.NewShareCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(audit.NewAuditCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = bin.RegisterCommand(expiring.NewExpiringCommand(filename))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	if err := bin.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "\U0001f480  %s\n", err.Error())
 		switch err.(type) {
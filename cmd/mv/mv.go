@@ -0,0 +1,198 @@
+package mv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type mvAction struct {
+	args     []string
+	group    string
+	move     bool
+	pattern  bool
+	filename string
+}
+
+const (
+	cmdName   = "mv"
+	shortDesc = "rename a record or move records to another group"
+	longDesc  = `Rename a record or move records to another group.
+
+Usage: %s %s <Record Title> <New Title>
+       %s %s -group <group> <Record Title>...
+       %s %s -group <group> -pattern <pattern>...
+
+ * the record keeps its UUID, creation time and password history
+ * the titles can be qualified by their group as in 'Group/Title'
+ * with -pattern all the records whose title matches one of the patterns are moved
+ * an empty -group moves the records to the top level
+`
+)
+
+// NewMvCommand create a 'mv' cli command
+func NewMvCommand(filename string) *cli.Command {
+	action := mvAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName, bin, cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *mvAction) handler() error {
+	if r.move && len(r.args) == 0 {
+		return utils.NewMissingParameterError("record title", cmdName)
+	}
+	if !r.move && len(r.args) != 2 {
+		return utils.NewMissingParameterError("record title and new title", cmdName)
+	}
+	if !r.move && r.pattern {
+		return fmt.Errorf("the -pattern flag requires -group")
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	if r.move {
+		err = r.moveRecords(db)
+	} else {
+		err = r.renameRecord(db)
+	}
+	if err != nil {
+		return err
+	}
+
+	// i.e. the records are already in the group or the new title is the same
+	if !db.NeedsSave() {
+		fmt.Printf("no changes, store '%s' not updated\n", r.filename)
+		return nil
+	}
+
+	err = pwsafe.WritePWSafeFile(db, r.filename)
+	if err == nil {
+		fmt.Printf("\U0001f44d records successfully updated in store '%s'\n", r.filename)
+	}
+
+	return err
+}
+
+func (r *mvAction) renameRecord(db pwsafe.DB) error {
+	rec, ok := utils.FindRecord(r.args[0], db)
+	if !ok {
+		return fmt.Errorf("record '%s' not found", r.args[0])
+	}
+
+	return db.RenameRecord(rec.Title, strings.TrimSpace(r.args[1]))
+}
+
+func (r *mvAction) moveRecords(db pwsafe.DB) error {
+	titles, err := r.matchingTitles(db)
+	if err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return fmt.Errorf("no records matching %s", strings.Join(r.args, ", "))
+	}
+
+	group := strings.TrimSpace(r.group)
+	for _, t := range titles {
+		rec, _ := db.GetRecord(t)
+		if rec.Group == group {
+			continue
+		}
+		rec.Group = group
+		db.SetRecord(rec)
+		fmt.Printf("%s -> %s\n", t, group)
+	}
+
+	return nil
+}
+
+// matchingTitles returns the titles selected by the command arguments
+func (r *mvAction) matchingTitles(db pwsafe.DB) ([]string, error) {
+	if !r.pattern {
+		titles := make([]string, 0, len(r.args))
+		for _, el := range r.args {
+			rec, ok := utils.FindRecord(el, db)
+			if !ok {
+				return nil, fmt.Errorf("record '%s' not found", el)
+			}
+			titles = append(titles, rec.Title)
+		}
+		return titles, nil
+	}
+
+	exps := make([]*regexp.Regexp, 0, len(r.args))
+	for _, el := range r.args {
+		exp, err := regexp.Compile(fmt.Sprintf("(?i)%s", el))
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+
+	var titles []string
+	for _, t := range db.List() {
+		for _, exp := range exps {
+			if exp.MatchString(t) {
+				titles = append(titles, t)
+				break
+			}
+		}
+	}
+	return titles, nil
+}
+
+func (r *mvAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.group), "group", "", "move the records to this group")
+		fs.BoolVar(&(r.pattern), "pattern", false, "match the record titles using the arguments as regular expressions")
+	}
+}
+
+func (r *mvAction) flagPostParser(fs *flag.FlagSet) {
+	r.args = fs.Args()
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "group" {
+			r.move = true
+		}
+	})
+}
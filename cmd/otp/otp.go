@@ -0,0 +1,120 @@
+package otp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type otpAction struct {
+	title    string
+	clip     bool
+	filename string
+}
+
+const (
+	cmdName   = "otp"
+	shortDesc = "generate the current TOTP code of a record"
+	longDesc  = `Generate the current TOTP code of the record with this title.
+
+Usage: %s %s [-clip] <Record Title>
+
+ * with -clip the code is copied to the clipboard instead of being printed
+ * import a TOTP secret with: push -otp-uri 'otpauth://totp/...' <Record Title>
+`
+)
+
+// NewOTPCommand create a 'otp' cli command
+func NewOTPCommand(filename string) *cli.Command {
+	action := otpAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *otpAction) handler() error {
+	if strings.TrimSpace(r.title) == "" {
+		return fmt.Errorf("missed record title")
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	rec, ok := utils.FindRecord(r.title, db)
+	if !ok {
+		return fmt.Errorf("record '%s' not found", r.title)
+	}
+
+	rec, err = db.ResolveRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	code, remaining, err := rec.OTP(time.Now())
+	if err != nil {
+		return err
+	}
+
+	secs := int(remaining.Seconds())
+	if r.clip {
+		if err := clipboard.WriteAll(code); err != nil {
+			return err
+		}
+		fmt.Printf("\U0001f44d check your clipboard for the code (valid for %ds)\n", secs)
+		return nil
+	}
+
+	fmt.Printf("%s (valid for %ds)\n", code, secs)
+	return nil
+}
+
+func (r *otpAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.BoolVar(&(r.clip), "clip", false, "copy the code to the clipboard")
+	}
+}
+
+func (r *otpAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.title = fs.Args()[0]
+	}
+}
@@ -16,6 +16,7 @@ import (
 type pullAction struct {
 	field    string
 	title    string
+	expand   bool
 	filename string
 }
 
@@ -24,9 +25,11 @@ const (
 	shortDesc = "fetch the content of the specified field"
 	longDesc  = `Fetch and show a field content of the record with this title.
 
-Usage: %s %s -field=user|pass|url|notes <Record Title>
+Usage: %s %s -field=user|pass|url|notes [-expand] <Record Title>
 
- * accepted values for 'field' are: user, pass, url, notes
+ * accepted values for 'field' are: user, pass, url, notes, email, runcmd, autotype
+ * with -expand the \u, \p, \g, \i, \l, \m, \o codes and the [[Title:field]]
+   references found in the field content are expanded
    `
 )
 
@@ -83,8 +86,7 @@ func (r *pullAction) handler() error {
 
 	for _, t := range titles {
 		if strings.EqualFold(r.title, t) {
-			pullFieldContent(r.field, t, db)
-			break
+			return pullFieldContent(r.field, t, r.expand, db)
 		}
 	}
 
@@ -95,6 +97,7 @@ func (r *pullAction) flagHandler(fn string) func(fs *flag.FlagSet) {
 	return func(fs *flag.FlagSet) {
 		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
 		fs.StringVar(&(r.field), "field", "pass", "the field to copy content - user, pass, url")
+		fs.BoolVar(&(r.expand), "expand", false, "expand the references found in the field content")
 	}
 }
 
@@ -104,41 +107,42 @@ func (r *pullAction) flagPostParser(fs *flag.FlagSet) {
 	}
 }
 
-func pullFieldContent(field, name string, db pwsafe.DB) {
+func pullFieldContent(field, name string, expand bool, db pwsafe.DB) error {
 	rec, ok := db.GetRecord(name)
 	if !ok {
-		return
+		return nil
+	}
+
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return err
 	}
 
+	var content string
 	switch strings.ToLower(field) {
 	case "pass":
-		fmt.Println(rec.Password)
+		content = rec.Password
 	case "user":
-		fmt.Println(rec.Username)
+		content = rec.Username
 	case "notes":
-		fmt.Println(rec.Notes)
+		content = rec.Notes
+	case "email":
+		content = rec.Email
+	case "runcmd":
+		content = rec.RunCommand
+	case "autotype":
+		content = rec.Autotype
 	default:
-		fmt.Println(rec.URL)
+		content = rec.URL
 	}
-}
 
-/*
-func dumpRecord(caption, name string, db pwsafe.DB) {
-	rec, ok := db.GetRecord(name)
-	if !ok {
-		return
+	if expand {
+		content, err = rec.Expand(content, db)
+		if err != nil {
+			return err
+		}
 	}
 
-	table := tablewriter.CreateTable()
-	table.AddTitle(caption)
-	table.AddRow(fmt.Sprintf("TITLE        : %s", rec.Title))
-	table.AddRow(fmt.Sprintf("GROUP        : %s", rec.Group))
-	table.AddRow(fmt.Sprintf("URL          : %s", rec.URL))
-	table.AddRow(fmt.Sprintf("USERNAME     : %s", rec.Username))
-	table.AddRow(fmt.Sprintf("PASSWORD     : %s", rec.Password))
-	table.AddRow(fmt.Sprintf("NOTES        : %s", rec.Notes))
-	table.AddRow(fmt.Sprintf("LAST UPDATED : %s", rec.ModTime.Format("2006-02-01")))
-
-	fmt.Println(table.Render())
+	fmt.Println(content)
+	return nil
 }
-*/
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/cli"
 
@@ -21,6 +22,10 @@ type pushAction struct {
 	url       string
 	withNotes bool
 	category  string
+	otpURI    string
+	aliasOf   string
+	shortcut  string
+	expires   string
 	filename  string
 }
 
@@ -31,6 +36,12 @@ const (
 
 Usage: %s %s [options] "Title"
 
+ * with -alias-of the record takes the password of the specified base record
+ * with -shortcut-of the record takes all the fields but title, category and username
+   of the specified base record
+ * with -expires (i.e. 90d, 12w, 1y) the password expires after that many days
+   since its last change, each time the password changes the expiry is moved
+   forward; use -expires never to remove it
 `
 )
 
@@ -113,6 +124,26 @@ func (r *pushAction) handler() error {
 		rec.Notes = strings.TrimSpace(notes)
 	}
 
+	if r.otpURI != "" {
+		if err := rec.SetOTPAuthURI(r.otpURI); err != nil {
+			return err
+		}
+	}
+
+	if r.expires != "" {
+		if err := setExpiry(&rec, r.expires); err != nil {
+			return err
+		}
+	}
+
+	if r.aliasOf != "" || r.shortcut != "" {
+		ref, err := baseReference(r.aliasOf, r.shortcut, db)
+		if err != nil {
+			return err
+		}
+		rec.Password = ref
+	}
+
 	db.SetRecord(rec)
 
 	err = pwsafe.WritePWSafeFile(db, r.filename)
@@ -149,6 +180,25 @@ func (r *pushAction) validateParams() (int, error) {
 		ret = ret + 1
 	}
 
+	if strings.TrimSpace(r.otpURI) != "" {
+		ret = ret + 1
+	}
+
+	if strings.TrimSpace(r.expires) != "" {
+		ret = ret + 1
+	}
+
+	if strings.TrimSpace(r.aliasOf) != "" && strings.TrimSpace(r.shortcut) != "" {
+		return 0, fmt.Errorf("-alias-of and -shortcut-of can not be used together")
+	}
+
+	if strings.TrimSpace(r.aliasOf) != "" || strings.TrimSpace(r.shortcut) != "" {
+		if strings.TrimSpace(r.password) != "" {
+			return 0, fmt.Errorf("-pass can not be used with -alias-of or -shortcut-of")
+		}
+		ret = ret + 1
+	}
+
 	return ret, nil
 }
 
@@ -162,6 +212,10 @@ func (r *pushAction) flagHandler(fn string) func(fs *flag.FlagSet) {
 		fs.StringVar(&(r.password), "pass", "", "the password")
 		fs.StringVar(&(r.url), "url", "", "the URL associated with the entry")
 		fs.BoolVar(&(r.withNotes), "note", false, "enter some additional note for this entry")
+		fs.StringVar(&(r.otpURI), "otp-uri", "", "the 'otpauth://totp/...' URI of the TOTP secret")
+		fs.StringVar(&(r.aliasOf), "alias-of", "", "the title of the record this one is an alias of")
+		fs.StringVar(&(r.shortcut), "shortcut-of", "", "the title of the record this one is a shortcut of")
+		fs.StringVar(&(r.expires), "expires", "", "the password expiry interval (i.e. 90d, 12w, 1y or never)")
 	}
 }
 
@@ -187,3 +241,52 @@ func findRecord(title string, db pwsafe.DB) pwsafe.Record {
 	}
 	return pwsafe.Record{}
 }
+
+// baseReference returns the alias or shortcut reference to the base record
+func baseReference(aliasOf, shortcutOf string, db pwsafe.DB) (string, error) {
+	title := aliasOf
+	if shortcutOf != "" {
+		title = shortcutOf
+	}
+
+	base := findRecord(title, db)
+	if base.Title == "" {
+		return "", fmt.Errorf("base record '%s' not found", title)
+	}
+
+	if kind, _ := base.EntryType(); kind != pwsafe.NormalEntry {
+		return "", fmt.Errorf("base record '%s' is itself an alias or a shortcut", base.Title)
+	}
+
+	if shortcutOf != "" {
+		return pwsafe.ShortcutReference(base), nil
+	}
+	return pwsafe.AliasReference(base), nil
+}
+
+// setExpiry sets the password expiry interval and the expiry since the last password change,
+// 'never' removes both
+func setExpiry(rec *pwsafe.Record, val string) error {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == "never" {
+		rec.PasswordExpiry = time.Time{}
+		return rec.SetPasswordExpiryDays(0)
+	}
+
+	d, err := utils.ParseDays(val)
+	if err != nil {
+		return err
+	}
+	if d <= 0 || d%(24*time.Hour) != 0 {
+		return fmt.Errorf("invalid expiry interval '%s' - it must be a number of days (i.e. 90d, 12w, 1y)", val)
+	}
+	if err := rec.SetPasswordExpiryDays(int(d / (24 * time.Hour))); err != nil {
+		return err
+	}
+
+	// new records get the expiry when created
+	if changed := rec.LastPasswordChange(); !changed.IsZero() {
+		rec.RenewPasswordExpiry(changed)
+	}
+	return nil
+}
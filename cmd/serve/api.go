@@ -0,0 +1,404 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+// api serves the JSON endpoints on top of a pwsafe.DB
+type api struct {
+	mu       sync.Mutex
+	db       pwsafe.DB
+	filename string
+	tokens   []apiToken
+}
+
+// recordView is the JSON representation of a record
+type recordView struct {
+	UUID            string     `json:"uuid"`
+	Title           string     `json:"title"`
+	Group           string     `json:"group,omitempty"`
+	Username        string     `json:"username,omitempty"`
+	Password        string     `json:"password,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	Notes           string     `json:"notes,omitempty"`
+	CreateTime      *time.Time `json:"create_time,omitempty"`
+	ModTime         *time.Time `json:"mod_time,omitempty"`
+	PasswordExpiry  *time.Time `json:"password_expiry,omitempty"`
+	PasswordModTime string     `json:"password_mod_time,omitempty"`
+}
+
+// recordInput holds the fields a client can set, nil fields are left untouched
+type recordInput struct {
+	Title    *string `json:"title"`
+	Group    *string `json:"group"`
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	URL      *string `json:"url"`
+	Email    *string `json:"email"`
+	Notes    *string `json:"notes"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func newAPI(db pwsafe.DB, filename string, tokens []apiToken) *api {
+	return &api{db: db, filename: filename, tokens: tokens}
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	token, ok := authenticate(req, a.tokens)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pwsafe"`)
+		writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := strings.TrimSuffix(req.URL.EscapedPath(), "/")
+	switch {
+	case path == "/v1/records":
+		switch req.Method {
+		case http.MethodGet:
+			a.listRecords(w, req, token)
+		case http.MethodPost:
+			a.createRecord(w, req, token)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case strings.HasPrefix(path, "/v1/records/"):
+		id, err := url.PathUnescape(strings.TrimPrefix(path, "/v1/records/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch req.Method {
+		case http.MethodGet:
+			a.getRecord(w, id, token)
+		case http.MethodPut:
+			a.updateRecord(w, req, id, token)
+		case http.MethodDelete:
+			a.deleteRecord(w, id, token)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case path == "/v1/groups" && req.Method == http.MethodGet:
+		a.listGroups(w, token)
+	case strings.HasPrefix(path, "/v1/groups/") && req.Method == http.MethodGet:
+		group, err := url.PathUnescape(strings.TrimPrefix(path, "/v1/groups/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		a.listGroup(w, group, token)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (a *api) listRecords(w http.ResponseWriter, req *http.Request, token apiToken) {
+	var exp *regexp.Regexp
+	if q := strings.TrimSpace(req.URL.Query().Get("q")); q != "" {
+		var err error
+		exp, err = regexp.Compile(fmt.Sprintf("(?i)%s", q))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	group, filterGroup := req.URL.Query()["group"]
+
+	res := make([]recordView, 0)
+	for _, t := range a.db.List() {
+		rec, ok := a.db.GetRecord(t)
+		if !ok || !token.canRead(rec.Group) {
+			continue
+		}
+		if filterGroup && rec.Group != group[0] {
+			continue
+		}
+		if exp != nil && !(exp.MatchString(rec.Title) || exp.MatchString(rec.Group) ||
+			exp.MatchString(rec.Username) || exp.MatchString(rec.URL)) {
+			continue
+		}
+		res = append(res, summaryView(rec))
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *api) getRecord(w http.ResponseWriter, id string, token apiToken) {
+	rec, ok := a.findRecord(id)
+	if !ok || !token.canRead(rec.Group) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s not found", id))
+		return
+	}
+
+	// aliases and shortcuts must not leak the data of a base entry the token can't read
+	if kind, baseID := rec.EntryType(); kind != pwsafe.NormalEntry {
+		if base, ok := a.db.GetRecordByUUID(baseID); ok && !token.canRead(base.Group) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("record %s not found", id))
+			return
+		}
+	}
+
+	rec, err := a.db.ResolveRecord(rec)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fullView(rec))
+}
+
+func (a *api) createRecord(w http.ResponseWriter, req *http.Request, token apiToken) {
+	var in recordInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		writeError(w, http.StatusBadRequest, "missing record title")
+		return
+	}
+	if in.Password == nil || *in.Password == "" {
+		writeError(w, http.StatusBadRequest, "missing record password")
+		return
+	}
+
+	var rec pwsafe.Record
+	in.apply(&rec)
+	if !token.canWrite(rec.Group) {
+		writeError(w, http.StatusForbidden, "token not allowed to write this record")
+		return
+	}
+
+	// the records the token can't read are not revealed, the creation is just forbidden
+	if other, exists := a.findRecord(rec.Title); exists {
+		if !token.canRead(other.Group) {
+			writeError(w, http.StatusForbidden, "token not allowed to write this record")
+			return
+		}
+		writeError(w, http.StatusConflict, fmt.Sprintf("record %s already exists", rec.Title))
+		return
+	}
+
+	if err := a.save(func() { a.db.SetRecord(rec) }, func() { a.db.DeleteRecord(rec.Title) }); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rec, _ = a.db.GetRecord(rec.Title)
+	writeJSON(w, http.StatusCreated, fullView(rec))
+}
+
+func (a *api) updateRecord(w http.ResponseWriter, req *http.Request, id string, token apiToken) {
+	rec, ok := a.findRecord(id)
+	if !ok || !token.canRead(rec.Group) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s not found", id))
+		return
+	}
+
+	var in recordInput
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if in.Title != nil && *in.Title != rec.Title {
+		writeError(w, http.StatusBadRequest, "renaming records is not supported")
+		return
+	}
+	if in.Password != nil && *in.Password == "" {
+		writeError(w, http.StatusBadRequest, "the password can not be empty")
+		return
+	}
+
+	if !token.canWrite(rec.Group) {
+		writeError(w, http.StatusForbidden, "token not allowed to write this record")
+		return
+	}
+	old := rec
+	in.apply(&rec)
+	if !token.canWrite(rec.Group) {
+		writeError(w, http.StatusForbidden, "token not allowed to move the record to this group")
+		return
+	}
+
+	if err := a.save(func() { a.db.SetRecord(rec) }, func() { a.db.ImportRecord(old) }); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rec, _ = a.db.GetRecord(rec.Title)
+	writeJSON(w, http.StatusOK, fullView(rec))
+}
+
+func (a *api) deleteRecord(w http.ResponseWriter, id string, token apiToken) {
+	rec, ok := a.findRecord(id)
+	if !ok || !token.canRead(rec.Group) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s not found", id))
+		return
+	}
+
+	if !token.canWrite(rec.Group) {
+		writeError(w, http.StatusForbidden, "token not allowed to delete this record")
+		return
+	}
+
+	if err := a.save(func() { a.db.DeleteRecord(rec.Title) }, func() { a.db.ImportRecord(rec) }); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *api) listGroups(w http.ResponseWriter, token apiToken) {
+	res := make([]string, 0)
+	for _, g := range a.db.Groups() {
+		if token.canRead(g) {
+			res = append(res, g)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *api) listGroup(w http.ResponseWriter, group string, token apiToken) {
+	if !token.canRead(group) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("group %s not found", group))
+		return
+	}
+
+	res := make([]recordView, 0)
+	for _, t := range a.db.ListByGroup(group) {
+		if rec, ok := a.db.GetRecord(t); ok {
+			res = append(res, summaryView(rec))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// save applies the change and writes the store, undoing the change when the write fails
+// so that the records served stay the ones in the file
+func (a *api) save(change, undo func()) error {
+	// the empty groups pruned by the change are restored along with the records
+	var emptyGroups []string
+	v3, isV3 := a.db.(*pwsafe.V3)
+	if isV3 {
+		emptyGroups = append([]string(nil), v3.EmptyGroups...)
+	}
+
+	change()
+	err := pwsafe.WritePWSafeFile(a.db, a.filename)
+	if err != nil {
+		undo()
+		if isV3 {
+			v3.EmptyGroups = emptyGroups
+		}
+	}
+	return err
+}
+
+// findRecord looks up a record by UUID first and then by title (case insensitive)
+func (a *api) findRecord(id string) (pwsafe.Record, bool) {
+	if u := uuid.Parse(id); u != nil {
+		if rec, ok := a.db.GetRecordByUUID(u.Array()); ok {
+			return rec, true
+		}
+	}
+
+	for _, t := range a.db.List() {
+		if strings.EqualFold(id, t) {
+			return a.db.GetRecord(t)
+		}
+	}
+
+	return pwsafe.Record{}, false
+}
+
+// apply copies the given fields into the record
+func (in recordInput) apply(rec *pwsafe.Record) {
+	if in.Title != nil {
+		rec.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Group != nil {
+		rec.Group = strings.TrimSpace(*in.Group)
+	}
+	if in.Username != nil {
+		rec.Username = strings.TrimSpace(*in.Username)
+	}
+	if in.Password != nil {
+		rec.Password = *in.Password
+	}
+	if in.URL != nil {
+		rec.URL = strings.TrimSpace(*in.URL)
+	}
+	if in.Email != nil {
+		rec.Email = strings.TrimSpace(*in.Email)
+	}
+	if in.Notes != nil {
+		rec.Notes = *in.Notes
+	}
+}
+
+// summaryView returns the record view without secrets
+func summaryView(rec pwsafe.Record) recordView {
+	return recordView{
+		UUID:     uuid.UUID(rec.UUID[:]).String(),
+		Title:    rec.Title,
+		Group:    rec.Group,
+		Username: rec.Username,
+		URL:      rec.URL,
+		Email:    rec.Email,
+	}
+}
+
+// fullView returns the record view including password and notes
+func fullView(rec pwsafe.Record) recordView {
+	v := summaryView(rec)
+	v.Password = rec.Password
+	v.Notes = rec.Notes
+	v.PasswordModTime = rec.PasswordModTime
+	v.CreateTime = timeOrNil(rec.CreateTime)
+	v.ModTime = timeOrNil(rec.ModTime)
+	v.PasswordExpiry = timeOrNil(rec.PasswordExpiry)
+	return v
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() || t.Unix() == 0 {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{Error: msg})
+}
@@ -0,0 +1,182 @@
+package serve
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasepe/pwsafe"
+)
+
+var testTokens = []apiToken{
+	{Token: "full"},
+	{Token: "infra", Groups: []string{"Infra"}},
+	{Token: "infra-ro", ReadOnly: true, Groups: []string{"Infra"}},
+}
+
+func newTestAPI(filename string) *api {
+	db := pwsafe.NewV3("test", "password")
+	db.SetRecord(pwsafe.Record{Title: "db", Group: "Infra.Prod", Username: "admin", Password: "s3cr3t"})
+	db.SetRecord(pwsafe.Record{Title: "bank", Group: "Bank", Username: "john", Password: "m0n3y"})
+	bank, _ := db.GetRecord("bank")
+	db.SetRecord(pwsafe.Record{Title: "bank alias", Group: "Infra", Password: pwsafe.AliasReference(bank)})
+	return newAPI(db, filename, testTokens)
+}
+
+func serveRequest(a *api, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIUnauthorized(t *testing.T) {
+	a := newTestAPI("")
+
+	for _, token := range []string{"", "nope"} {
+		res := serveRequest(a, token, http.MethodGet, "/v1/records", "")
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Equal(t, `Bearer realm="pwsafe"`, res.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestAPIReadScope(t *testing.T) {
+	a := newTestAPI("")
+
+	titles := func(token string) []string {
+		resp := serveRequest(a, token, http.MethodGet, "/v1/records", "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var views []recordView
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &views))
+		var res []string
+		for _, v := range views {
+			assert.Equal(t, "", v.Password)
+			res = append(res, v.Title)
+		}
+		sort.Strings(res)
+		return res
+	}
+	assert.Equal(t, []string{"bank", "bank alias", "db"}, titles("full"))
+	assert.Equal(t, []string{"bank alias", "db"}, titles("infra"))
+
+	res := serveRequest(a, "infra", http.MethodGet, "/v1/records/db", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"password": "s3cr3t"`)
+
+	res = serveRequest(a, "infra", http.MethodGet, "/v1/records/bank", "")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	// the alias is readable but its base entry is not
+	res = serveRequest(a, "infra", http.MethodGet, "/v1/records/bank%20alias", "")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.NotContains(t, res.Body.String(), "m0n3y")
+
+	res = serveRequest(a, "full", http.MethodGet, "/v1/records/bank%20alias", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"password": "m0n3y"`)
+
+	res = serveRequest(a, "infra", http.MethodGet, "/v1/groups", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotContains(t, res.Body.String(), `"Bank"`)
+
+	res = serveRequest(a, "infra", http.MethodGet, "/v1/groups/Bank", "")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestAPIWriteScope(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newTestAPI(filepath.Join(dir, "test.dat"))
+
+	res := serveRequest(a, "infra-ro", http.MethodPut, "/v1/records/db", `{"password": "changed"}`)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveRequest(a, "infra-ro", http.MethodDelete, "/v1/records/db", "")
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveRequest(a, "infra-ro", http.MethodPost, "/v1/records", `{"title": "new", "group": "Infra", "password": "x"}`)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveRequest(a, "infra", http.MethodPost, "/v1/records", `{"title": "new", "group": "Bank", "password": "x"}`)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveRequest(a, "infra", http.MethodPut, "/v1/records/db", `{"group": "Bank"}`)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	res = serveRequest(a, "infra", http.MethodPut, "/v1/records/bank", `{"password": "changed"}`)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	// the titles of the records the token can't read are not revealed
+	res = serveRequest(a, "infra", http.MethodPost, "/v1/records", `{"title": "bank", "group": "Infra", "password": "x"}`)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.NotContains(t, res.Body.String(), "exists")
+
+	res = serveRequest(a, "infra", http.MethodPost, "/v1/records", `{"title": "db", "group": "Infra", "password": "x"}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+
+	res = serveRequest(a, "full", http.MethodPost, "/v1/records", `{"title": "bank", "password": "x"}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+
+	rec, _ := a.db.GetRecord("db")
+	assert.Equal(t, "s3cr3t", rec.Password)
+	assert.Equal(t, "Infra.Prod", rec.Group)
+	_, ok := a.db.GetRecord("new")
+	assert.False(t, ok)
+	rec, _ = a.db.GetRecord("bank")
+	assert.Equal(t, "m0n3y", rec.Password)
+
+	res = serveRequest(a, "infra", http.MethodPut, "/v1/records/db", `{"password": "changed"}`)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	db, err := pwsafe.OpenPWSafeFile(a.filename, "password")
+	if assert.Nil(t, err) {
+		rec, _ := db.GetRecord("db")
+		assert.Equal(t, "changed", rec.Password)
+	}
+}
+
+func TestAPIWriteFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the store can't be written in a missing directory
+	a := newTestAPI(filepath.Join(dir, "missing", "test.dat"))
+	before, _ := a.db.GetRecord("db")
+	assert.Nil(t, a.db.AddEmptyGroup("Infra.Empty"))
+	emptyGroups := a.db.(*pwsafe.V3).EmptyGroups
+
+	res := serveRequest(a, "full", http.MethodPost, "/v1/records", `{"title": "new", "group": "Infra.Empty", "password": "x"}`)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	_, ok := a.db.GetRecord("new")
+	assert.False(t, ok)
+	assert.Equal(t, emptyGroups, a.db.(*pwsafe.V3).EmptyGroups)
+
+	res = serveRequest(a, "full", http.MethodPut, "/v1/records/db", `{"password": "changed", "group": "Infra.Empty"}`)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	rec, _ := a.db.GetRecord("db")
+	assert.Equal(t, before, rec)
+	assert.Equal(t, emptyGroups, a.db.(*pwsafe.V3).EmptyGroups)
+
+	res = serveRequest(a, "full", http.MethodDelete, "/v1/records/db", "")
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	rec, _ = a.db.GetRecord("db")
+	assert.Equal(t, before, rec)
+}
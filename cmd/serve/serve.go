@@ -0,0 +1,195 @@
+package serve
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type serveAction struct {
+	listen    string
+	socket    string
+	token     string
+	tokenFile string
+	filename  string
+}
+
+const (
+	cmdName   = "serve"
+	shortDesc = "expose the records through a local JSON API"
+	longDesc  = `Serve a JSON API over a unix socket or a localhost TCP address.
+
+Usage: %s %s [options]
+
+ * every request must carry an 'Authorization: Bearer <token>' header
+ * the token can be given with -token or with the PWSAFE_API_TOKEN variable
+ * scoped tokens (read-only, group restricted) are loaded from -tokens
+   a JSON file like: [{"token": "...", "read_only": true, "groups": ["Infra"]}]
+
+Endpoints:
+
+  GET    /v1/records[?group=<group>&q=<pattern>]
+  POST   /v1/records
+  GET    /v1/records/<uuid|title>
+  PUT    /v1/records/<uuid|title>
+  DELETE /v1/records/<uuid|title>
+  GET    /v1/groups
+  GET    /v1/groups/<group>
+`
+)
+
+// NewServeCommand create a 'serve' cli command
+func NewServeCommand(filename string) *cli.Command {
+	action := serveAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+	}
+
+	return cmd
+}
+
+func (r *serveAction) handler() error {
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := r.loadTokens()
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	ln, err := r.listener()
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:      newAPI(db, r.filename, tokens),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	fmt.Printf("\U0001f44d serving '%s' on %s (CTRL+C to stop)\n", r.filename, ln.Addr())
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+
+	return <-done
+}
+
+func (r *serveAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.listen), "listen", "127.0.0.1:8754", "the localhost TCP address to listen on")
+		fs.StringVar(&(r.socket), "socket", "", "the unix socket to listen on (overrides -listen)")
+		fs.StringVar(&(r.token), "token", os.Getenv("PWSAFE_API_TOKEN"), "the bearer token granting full access")
+		fs.StringVar(&(r.tokenFile), "tokens", "", "a JSON file with scoped bearer tokens")
+	}
+}
+
+// listener opens the unix socket or the loopback TCP address.
+func (r *serveAction) listener() (net.Listener, error) {
+	if strings.TrimSpace(r.socket) != "" {
+		if _, err := os.Stat(r.socket); err == nil {
+			if err := os.Remove(r.socket); err != nil {
+				return nil, err
+			}
+		}
+
+		ln, err := listenUnix(r.socket)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Chmod(r.socket, 0600); err != nil {
+			ln.Close()
+			return nil, err
+		}
+
+		return ln, nil
+	}
+
+	host, _, err := net.SplitHostPort(r.listen)
+	if err != nil {
+		return nil, err
+	}
+
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("refusing to listen on non-loopback address %s", r.listen)
+		}
+	}
+
+	return net.Listen("tcp", r.listen)
+}
+
+// loadTokens collects the full access token and the scoped ones.
+func (r *serveAction) loadTokens() ([]apiToken, error) {
+	var tokens []apiToken
+	if strings.TrimSpace(r.token) != "" {
+		tokens = append(tokens, apiToken{Token: strings.TrimSpace(r.token)})
+	}
+
+	if strings.TrimSpace(r.tokenFile) != "" {
+		scoped, err := readTokenFile(r.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, scoped...)
+	}
+
+	if len(tokens) == 0 {
+		return nil, utils.NewMissingParameterError("token", cmdName)
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package serve
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix opens the unix socket, that is created readable and writable by the owner only
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,8 @@
+package serve
+
+import "net"
+
+// listenUnix opens the unix socket, the umask does not apply on windows
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
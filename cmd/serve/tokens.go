@@ -0,0 +1,75 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// apiToken is a bearer token with its optional restrictions
+type apiToken struct {
+	Token    string   `json:"token"`
+	ReadOnly bool     `json:"read_only"`
+	Groups   []string `json:"groups"`
+}
+
+// readTokenFile loads the scoped tokens from a JSON file
+func readTokenFile(fn string) ([]apiToken, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []apiToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid tokens file %s: %s", fn, err.Error())
+	}
+
+	for i, t := range tokens {
+		if strings.TrimSpace(t.Token) == "" {
+			return nil, fmt.Errorf("invalid tokens file %s: entry %d has an empty token", fn, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+// canRead returns true if the token is allowed to access records of the given group
+func (t apiToken) canRead(group string) bool {
+	if len(t.Groups) == 0 {
+		return true
+	}
+
+	for _, g := range t.Groups {
+		if group == g || strings.HasPrefix(group, g+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canWrite returns true if the token is allowed to modify records of the given group
+func (t apiToken) canWrite(group string) bool {
+	return !t.ReadOnly && t.canRead(group)
+}
+
+// authenticate returns the token matching the request bearer token
+func authenticate(req *http.Request, tokens []apiToken) (apiToken, bool) {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return apiToken{}, false
+	}
+	bearer := []byte(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(bearer, []byte(t.Token)) == 1 {
+			return t, true
+		}
+	}
+
+	return apiToken{}, false
+}
@@ -0,0 +1,98 @@
+package serve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenScopes(t *testing.T) {
+	full := apiToken{Token: "full"}
+	assert.True(t, full.canRead(""))
+	assert.True(t, full.canRead("Infra.Prod"))
+	assert.True(t, full.canWrite("Infra.Prod"))
+
+	scoped := apiToken{Token: "scoped", Groups: []string{"Infra", "Bank.Personal"}}
+	assert.True(t, scoped.canRead("Infra"))
+	assert.True(t, scoped.canRead("Infra.Prod"))
+	assert.True(t, scoped.canRead("Bank.Personal"))
+	assert.True(t, scoped.canWrite("Infra.Prod"))
+	assert.False(t, scoped.canRead(""))
+	assert.False(t, scoped.canRead("Infrastructure"))
+	assert.False(t, scoped.canRead("Bank"))
+	assert.False(t, scoped.canRead("Prod.Infra"))
+
+	readOnly := apiToken{Token: "ro", ReadOnly: true, Groups: []string{"Infra"}}
+	assert.True(t, readOnly.canRead("Infra"))
+	assert.False(t, readOnly.canWrite("Infra"))
+	assert.False(t, readOnly.canWrite("Bank"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens := []apiToken{{Token: "full"}, {Token: "ro", ReadOnly: true}}
+
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"", "", false},
+		{"Bearer", "", false},
+		{"Bearer ", "", false},
+		{"Bearer nope", "", false},
+		{"Basic full", "", false},
+		{"bearer full", "", false},
+		{"Bearer fullx", "", false},
+		{"Bearer full", "full", true},
+		{"Bearer  ro ", "ro", true},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/records", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		token, ok := authenticate(req, tokens)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.token, token.Token, tt.header)
+	}
+}
+
+func TestReadTokenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwsafe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "tokens.json")
+	write := func(data string) {
+		if err := ioutil.WriteFile(fn, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`[{"token": "abc", "read_only": true, "groups": ["Infra"]}, {"token": "def"}]`)
+	tokens, err := readTokenFile(fn)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []apiToken{
+			{Token: "abc", ReadOnly: true, Groups: []string{"Infra"}},
+			{Token: "def"},
+		}, tokens)
+	}
+
+	write(`[{"token": "abc"}, {"token": " ", "groups": ["Infra"]}]`)
+	_, err = readTokenFile(fn)
+	assert.NotNil(t, err)
+
+	write(`{"token": "abc"}`)
+	_, err = readTokenFile(fn)
+	assert.NotNil(t, err)
+
+	_, err = readTokenFile(filepath.Join(dir, "missing.json"))
+	assert.NotNil(t, err)
+}
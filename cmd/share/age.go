@@ -0,0 +1,220 @@
+package share
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// A minimal writer of the age v1 format (https://age-encryption.org/v1)
+// encrypting to a single X25519 recipient, so that the colleague can decrypt
+// the secret phrase with the standard age tools.
+
+const (
+	ageIntro         = "age-encryption.org/v1\n"
+	ageX25519Label   = "age-encryption.org/v1/X25519"
+	ageRecipientHRP  = "age"
+	ageFileKeySize   = 16
+	ageStreamNonce   = 16
+	ageChunkSize     = 64 * 1024
+	ageColumnsPerRow = 64
+)
+
+var ageBase64 = base64.RawStdEncoding
+
+// parseAgeRecipient decodes an age X25519 public key (age1...)
+func parseAgeRecipient(s string) ([]byte, error) {
+	hrp, data, err := bech32Decode(strings.TrimSpace(s))
+	if err != nil || hrp != ageRecipientHRP || len(data) != curve25519.PointSize {
+		return nil, fmt.Errorf("invalid age recipient '%s' - expected an age1... public key", s)
+	}
+	return data, nil
+}
+
+// ageEncrypt encrypts the data to the X25519 recipient
+func ageEncrypt(recipient, data []byte) ([]byte, error) {
+	fileKey := make([]byte, ageFileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	stanza, err := ageX25519Stanza(recipient, fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(ageIntro)
+	buf.WriteString(stanza)
+	buf.WriteString("---")
+	mac := hmac.New(sha256.New, ageHKDF(fileKey, nil, "header"))
+	mac.Write(buf.Bytes())
+	buf.WriteString(" " + ageBase64.EncodeToString(mac.Sum(nil)) + "\n")
+
+	nonce := make([]byte, ageStreamNonce)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	buf.Write(nonce)
+
+	aead, err := chacha20poly1305.New(ageHKDF(fileKey, nonce, "payload"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the STREAM construction: the nonce is the chunk counter and the last chunk flag
+	var chunkNonce [chacha20poly1305.NonceSize]byte
+	for counter := uint64(0); ; counter++ {
+		chunk := data
+		if len(chunk) > ageChunkSize {
+			chunk = chunk[:ageChunkSize]
+		}
+		data = data[len(chunk):]
+
+		for i := 0; i < 8; i++ {
+			chunkNonce[10-i] = byte(counter >> (8 * uint(i)))
+		}
+		if len(data) == 0 {
+			chunkNonce[11] = 1
+		}
+		buf.Write(aead.Seal(nil, chunkNonce[:], chunk, nil))
+
+		if len(data) == 0 {
+			break
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ageX25519Stanza wraps the file key for the recipient
+func ageX25519Stanza(recipient, fileKey []byte) (string, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return "", err
+	}
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return "", err
+	}
+	shared, err := curve25519.X25519(ephemeral, recipient)
+	if err != nil {
+		return "", err
+	}
+
+	salt := append(append([]byte{}, share...), recipient...)
+	aead, err := chacha20poly1305.New(ageHKDF(shared, salt, ageX25519Label))
+	if err != nil {
+		return "", err
+	}
+	body := aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil)
+
+	var sb strings.Builder
+	sb.WriteString("-> X25519 " + ageBase64.EncodeToString(share) + "\n")
+	enc := ageBase64.EncodeToString(body)
+	for {
+		line := enc
+		if len(line) > ageColumnsPerRow {
+			line = line[:ageColumnsPerRow]
+		}
+		enc = enc[len(line):]
+		sb.WriteString(line + "\n")
+		// a full line is followed by a shorter (maybe empty) one
+		if len(line) < ageColumnsPerRow {
+			break
+		}
+	}
+	return sb.String(), nil
+}
+
+// ageHKDF derives a 32 bytes key with HKDF-SHA-256
+func ageHKDF(key, salt []byte, label string) []byte {
+	res := make([]byte, chacha20poly1305.KeySize)
+	io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(label)), res)
+	return res
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Decode decodes a lowercase or uppercase bech32 string (BIP 173) into its human readable part and data
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("mixed case bech32 string")
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndex(s, "1")
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid bech32 separator position")
+	}
+	hrp := s[:pos]
+
+	values := make([]byte, 0, len(s)-pos-1)
+	for _, c := range s[pos+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character '%c'", c)
+		}
+		values = append(values, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+
+	// convert the 5 bit groups, without the checksum, to bytes
+	var (
+		acc  uint32
+		bits uint
+		data []byte
+	)
+	for _, v := range values[:len(values)-6] {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, fmt.Errorf("invalid bech32 padding")
+	}
+
+	return hrp, data, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	res := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		res = append(res, byte(c>>5))
+	}
+	res = append(res, 0)
+	for _, c := range hrp {
+		res = append(res, byte(c&31))
+	}
+	return res
+}
@@ -0,0 +1,205 @@
+package share
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+// Test vectors from BIP 173
+func TestBech32DecodeValid(t *testing.T) {
+	tests := []struct {
+		s   string
+		hrp string
+	}{
+		{"A12UEL5L", "a"},
+		{"a12uel5l", "a"},
+		{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio"},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", "abcdef"},
+		{"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", "1"},
+		{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", "split"},
+		{"?1ezyfcl", "?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			hrp, _, err := bech32Decode(tt.s)
+			if err != nil && strings.Contains(err.Error(), "padding") {
+				// the vectors hold arbitrary 5 bit groups, not always bytes
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.hrp, hrp)
+			}
+		})
+	}
+}
+
+// Test vectors from BIP 173
+func TestBech32DecodeInvalid(t *testing.T) {
+	tests := []string{
+		"pzry9x0s0muk",  // no separator character
+		"1pzry9x0s0muk", // empty HRP
+		"x1b4n0q5v",     // invalid data character
+		"li1dgmt3",      // too short checksum
+		"A1G7SGD8",      // checksum calculated with uppercase form of HRP
+		"10a06t8",       // empty HRP
+		"1qzzfhee",      // empty HRP
+		"a12UEL5L",      // mixed case
+		"a12uel5m",      // invalid checksum
+		"de1lg7wt\xff",  // invalid character in checksum
+		"abcdef1qpzrz9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", // invalid data
+	}
+
+	for _, s := range tests {
+		_, _, err := bech32Decode(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseAgeRecipient(t *testing.T) {
+	// from the age specification
+	key, err := parseAgeRecipient("age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj")
+	if assert.Nil(t, err) {
+		assert.Equal(t, curve25519.PointSize, len(key))
+	}
+
+	_, err = parseAgeRecipient("age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwk")
+	assert.NotNil(t, err)
+
+	// a valid bech32 string, not an age public key
+	_, err = parseAgeRecipient("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw")
+	assert.NotNil(t, err)
+}
+
+func TestAgeEncrypt(t *testing.T) {
+	identity := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(identity); err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := curve25519.X25519(identity, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 32, ageChunkSize, ageChunkSize + 1, 2*ageChunkSize + 100} {
+		t.Run(fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+			data := make([]byte, size)
+			rand.Read(data)
+
+			enc, err := ageEncrypt(recipient, data)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			dec, err := ageDecrypt(identity, enc)
+			if assert.Nil(t, err) {
+				assert.True(t, bytes.Equal(data, dec))
+			}
+		})
+	}
+}
+
+// ageDecrypt decrypts the age file with the X25519 identity, following the specification
+func ageDecrypt(identity, enc []byte) ([]byte, error) {
+	if !bytes.HasPrefix(enc, []byte(ageIntro)) {
+		return nil, fmt.Errorf("missing intro")
+	}
+	end := bytes.Index(enc, []byte("\n---"))
+	if end < 0 {
+		return nil, fmt.Errorf("missing header end")
+	}
+	lines := strings.Split(string(enc[len(ageIntro):end]), "\n")
+	rest := enc[end+1:]
+	nl := bytes.IndexByte(rest, '\n')
+	if nl < 0 {
+		return nil, fmt.Errorf("missing header MAC")
+	}
+	header, macLine, payload := enc[:end+4], string(rest[:nl]), rest[nl+1:]
+
+	args := strings.Fields(lines[0])
+	if len(args) != 3 || args[0] != "->" || args[1] != "X25519" {
+		return nil, fmt.Errorf("unexpected stanza '%s'", lines[0])
+	}
+	share, err := ageBase64.DecodeString(args[2])
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines[1 : len(lines)-1] {
+		if len(l) != ageColumnsPerRow {
+			return nil, fmt.Errorf("short stanza body line")
+		}
+	}
+	body, err := ageBase64.DecodeString(strings.Join(lines[1:], ""))
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := curve25519.X25519(identity, share)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := curve25519.X25519(identity, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(ageHKDF(shared, append(share, recipient...), ageX25519Label))
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, ageHKDF(fileKey, nil, "header"))
+	mac.Write(header)
+	if macLine != "--- "+ageBase64.EncodeToString(mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid header MAC")
+	}
+
+	if len(payload) < ageStreamNonce {
+		return nil, fmt.Errorf("missing payload nonce")
+	}
+	aead, err = chacha20poly1305.New(ageHKDF(fileKey, payload[:ageStreamNonce], "payload"))
+	if err != nil {
+		return nil, err
+	}
+	payload = payload[ageStreamNonce:]
+
+	var (
+		res   []byte
+		nonce [chacha20poly1305.NonceSize]byte
+	)
+	for counter := uint64(0); ; counter++ {
+		chunk := payload
+		if len(chunk) > ageChunkSize+aead.Overhead() {
+			chunk = chunk[:ageChunkSize+aead.Overhead()]
+		}
+		payload = payload[len(chunk):]
+
+		for i := 0; i < 8; i++ {
+			nonce[10-i] = byte(counter >> (8 * uint(i)))
+		}
+		if len(payload) == 0 {
+			nonce[11] = 1
+		}
+		plain, err := aead.Open(nil, nonce[:], chunk, nil)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, plain...)
+
+		if len(payload) == 0 {
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lucasepe/cli"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type shareAction struct {
+	args      []string
+	group     string
+	pattern   bool
+	to        string
+	recipient string
+	filename  string
+}
+
+const (
+	cmdName   = "share"
+	shortDesc = "create a new store holding only some records"
+	longDesc  = `Create a new password store holding only the selected records, to hand them over to a colleague.
+
+Usage: %s %s -to <new store> -group <group> [<Record Title>...]
+       %s %s -to <new store> [-pattern] <Record Title>...
+       %s %s -to <new store> -recipient age1... -group <group>
+
+ * with -group all the records of the group (and its sub groups) are shared
+ * the titles can be qualified by their group as in 'Group/Title'
+ * with -pattern all the records whose title matches one of the patterns are shared
+ * the records keep their UUID, times and password history
+ * aliases and shortcuts whose base entry is not shared get the data of the base entry
+ * the new store is encrypted with a new secret phrase, or with -recipient with a random
+   one encrypted to the colleague's age public key in a '.key.age' file named as the new
+   store without its extension (i.e. colleague.key.age for colleague.dat):
+   'age -d -i <identity file> colleague.key.age' prints the secret phrase
+`
+
+	// the length of the random secret phrase used with -recipient
+	randomSecretLength = 32
+)
+
+// NewShareCommand create a 'share' cli command
+func NewShareCommand(filename string) *cli.Command {
+	action := shareAction{}
+
+	bin := filepath.Base(os.Args[0])
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, bin, cmdName, bin, cmdName, bin, cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *shareAction) handler() error {
+	if len(r.args) == 0 && strings.TrimSpace(r.group) == "" {
+		return utils.NewMissingParameterError("record title or group", cmdName)
+	}
+	if strings.TrimSpace(r.to) == "" {
+		return utils.NewMissingParameterError("to", cmdName)
+	}
+
+	var recipient []byte
+	if r.recipient != "" {
+		var err error
+		if recipient, err = parseAgeRecipient(r.recipient); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	r.filename, err = utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.to, err = utils.GetAbsolutePath(r.to)
+	if err != nil {
+		return err
+	}
+	if ok, _ := utils.FileExist(r.to); ok {
+		return utils.NewFileAlreadyExistError(r.to)
+	}
+	keyFile := keyFilename(r.to)
+	if ok, _ := utils.FileExist(keyFile); ok && recipient != nil {
+		return utils.NewFileAlreadyExistError(keyFile)
+	}
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	src, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	titles, err := r.matchingTitles(src)
+	if err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return fmt.Errorf("no records to share")
+	}
+
+	var newSecret string
+	if recipient == nil {
+		fmt.Printf("Secret phrase of the new store '%s'\n", r.to)
+		if newSecret, err = utils.GetSecretPhraseDoubleCheck(); err != nil {
+			return err
+		}
+	} else if newSecret, err = randomSecret(); err != nil {
+		return err
+	}
+
+	name := filepath.Base(r.to)
+	dst := pwsafe.NewV3(strings.TrimSuffix(name, filepath.Ext(name)), newSecret)
+	if err := shareRecords(titles, src, dst); err != nil {
+		return err
+	}
+	if r.group != "" {
+		shareEmptyGroups(r.group, src, dst)
+	}
+
+	err = pwsafe.WritePWSafeFile(dst, r.to)
+	if err != nil {
+		return err
+	}
+
+	if recipient != nil {
+		enc, err := ageEncrypt(recipient, []byte(newSecret+"\n"))
+		if err == nil {
+			err = utils.WritePrivateFile(keyFile, enc)
+		}
+		if err != nil {
+			// the store is useless without its secret phrase
+			os.Remove(r.to)
+			return err
+		}
+	}
+
+	fmt.Printf("\U0001f44d %d records successfully shared to store '%s'\n", len(titles), r.to)
+	if recipient != nil {
+		fmt.Printf("the secret phrase is encrypted to the recipient in '%s'\n", keyFile)
+	}
+	return nil
+}
+
+// shareRecords copies the records to the new store, the aliases and shortcuts
+// whose base entry is not shared are resolved since the colleague has no access to it
+func shareRecords(titles []string, src pwsafe.DB, dst pwsafe.DB) error {
+	shared := make(map[[16]byte]bool, len(titles))
+	for _, t := range titles {
+		rec, _ := src.GetRecord(t)
+		shared[rec.UUID] = true
+	}
+
+	for _, t := range titles {
+		rec, _ := src.GetRecord(t)
+		if kind, id := rec.EntryType(); kind != pwsafe.NormalEntry && !shared[id] {
+			resolved, err := src.ResolveRecord(rec)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("'%s' is a reference to a record not shared, its data is copied\n", t)
+			rec = resolved
+		}
+		dst.ImportRecord(rec)
+	}
+	return nil
+}
+
+// shareEmptyGroups copies the empty groups nested in the shared group
+func shareEmptyGroups(group string, src pwsafe.DB, dst pwsafe.DB) {
+	node := src.GroupTree().Find(group)
+	if node == nil {
+		return
+	}
+
+	node.Walk(func(n *pwsafe.GroupNode, depth int) error {
+		if len(n.Groups) == 0 && n.RecordCount() == 0 {
+			dst.AddEmptyGroup(n.Path)
+		}
+		return nil
+	})
+}
+
+// matchingTitles returns the titles of the records in the group and the ones selected by the arguments
+func (r *shareAction) matchingTitles(db pwsafe.DB) ([]string, error) {
+	selected := make(map[string]bool)
+
+	if group := strings.TrimSpace(r.group); group != "" {
+		if db.GroupTree().Find(group) == nil {
+			return nil, fmt.Errorf("group '%s' not found", group)
+		}
+		for _, t := range db.List() {
+			if rec, _ := db.GetRecord(t); pwsafe.InGroup(rec.Group, group) {
+				selected[t] = true
+			}
+		}
+	}
+
+	if !r.pattern {
+		for _, el := range r.args {
+			rec, ok := utils.FindRecord(el, db)
+			if !ok {
+				return nil, fmt.Errorf("record '%s' not found", el)
+			}
+			selected[rec.Title] = true
+		}
+	} else {
+		for _, el := range r.args {
+			exp, err := regexp.Compile(fmt.Sprintf("(?i)%s", el))
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range db.List() {
+				if exp.MatchString(t) {
+					selected[t] = true
+				}
+			}
+		}
+	}
+
+	titles := make([]string, 0, len(selected))
+	for t := range selected {
+		titles = append(titles, t)
+	}
+	sort.Strings(titles)
+	return titles, nil
+}
+
+// keyFilename returns the name of the file holding the encrypted secret phrase of the store
+func keyFilename(fn string) string {
+	return strings.TrimSuffix(fn, filepath.Ext(fn)) + ".key.age"
+}
+
+// randomSecret returns a random secret phrase
+func randomSecret() (string, error) {
+	b := make([]byte, randomSecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (r *shareAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.StringVar(&(r.to), "to", "", "the new password store file")
+		fs.StringVar(&(r.group), "group", "", "share all the records of this group")
+		fs.BoolVar(&(r.pattern), "pattern", false, "match the record titles using the arguments as regular expressions")
+		fs.StringVar(&(r.recipient), "recipient", "", "the age public key (age1...) of the colleague")
+	}
+}
+
+func (r *shareAction) flagPostParser(fs *flag.FlagSet) {
+	r.args = fs.Args()
+}
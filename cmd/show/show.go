@@ -0,0 +1,279 @@
+package show
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/cli"
+	"github.com/lucasepe/tablewriter"
+	"github.com/pborman/uuid"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/lucasepe/pwsafe"
+	utils "github.com/lucasepe/pwsafe/cmd/internal"
+)
+
+type showAction struct {
+	title    string
+	reveal   bool
+	output   string
+	filename string
+}
+
+// recordView holds the populated fields of a record
+type recordView struct {
+	UUID               string     `json:"uuid" yaml:"uuid"`
+	Title              string     `json:"title" yaml:"title"`
+	Group              string     `json:"group,omitempty" yaml:"group,omitempty"`
+	Username           string     `json:"username,omitempty" yaml:"username,omitempty"`
+	Password           string     `json:"password,omitempty" yaml:"password,omitempty"`
+	URL                string     `json:"url,omitempty" yaml:"url,omitempty"`
+	Email              string     `json:"email,omitempty" yaml:"email,omitempty"`
+	Notes              string     `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Autotype           string     `json:"autotype,omitempty" yaml:"autotype,omitempty"`
+	RunCommand         string     `json:"run_command,omitempty" yaml:"run_command,omitempty"`
+	CardNumber         string     `json:"card_number,omitempty" yaml:"card_number,omitempty"`
+	CardExpiration     string     `json:"card_expiration,omitempty" yaml:"card_expiration,omitempty"`
+	CardVerifValue     string     `json:"card_cvv,omitempty" yaml:"card_cvv,omitempty"`
+	CardPIN            string     `json:"card_pin,omitempty" yaml:"card_pin,omitempty"`
+	AliasOf            string     `json:"alias_of,omitempty" yaml:"alias_of,omitempty"`
+	ShortcutOf         string     `json:"shortcut_of,omitempty" yaml:"shortcut_of,omitempty"`
+	OTP                bool       `json:"otp,omitempty" yaml:"otp,omitempty"`
+	PasswordPolicyName string     `json:"password_policy_name,omitempty" yaml:"password_policy_name,omitempty"`
+	PasswordHistory    int        `json:"password_history,omitempty" yaml:"password_history,omitempty"`
+	CreateTime         *time.Time `json:"create_time,omitempty" yaml:"create_time,omitempty"`
+	ModTime            *time.Time `json:"mod_time,omitempty" yaml:"mod_time,omitempty"`
+	PasswordModTime    *time.Time `json:"password_mod_time,omitempty" yaml:"password_mod_time,omitempty"`
+	PasswordExpiry     *time.Time `json:"password_expiry,omitempty" yaml:"password_expiry,omitempty"`
+}
+
+const (
+	cmdName   = "show"
+	shortDesc = "show all the fields of a record"
+	longDesc  = `Show all the populated fields of the record with this title.
+
+Usage: %s %s [-reveal] [-o text|json|yaml] <Record Title>
+
+ * the password and the card number, CVV and PIN are masked unless -reveal is specified
+ * the title can be qualified by its group as in 'Group/Title'
+ * aliases and shortcuts show the data of their base entry
+`
+
+	passwordMask = "********"
+)
+
+// NewShowCommand create a 'show' cli command
+func NewShowCommand(filename string) *cli.Command {
+	action := showAction{}
+
+	cmd := &cli.Command{
+		Name:             cmdName,
+		ShortDescription: shortDesc,
+		Action:           action.handler,
+		Documentation:    fmt.Sprintf(longDesc, filepath.Base(os.Args[0]), cmdName),
+		FlagInit:         action.flagHandler(filename),
+		FlagPostParse:    action.flagPostParser,
+	}
+
+	return cmd
+}
+
+func (r *showAction) handler() error {
+	if strings.TrimSpace(r.title) == "" {
+		return fmt.Errorf("missed record title")
+	}
+
+	switch r.output {
+	case "text", "json", "yaml":
+	default:
+		return fmt.Errorf("invalid output format '%s' - accepted values are: text, json, yaml", r.output)
+	}
+
+	p, err := utils.GetAbsolutePath(r.filename)
+	if err != nil {
+		return err
+	}
+	r.filename = p
+
+	_, err = utils.FileExist(r.filename)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.GetEncryptedSecretPhrase(r.filename)
+	if err != nil {
+		secret, err = utils.GetSecretPhrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := pwsafe.OpenPWSafeFile(r.filename, secret)
+	if err != nil {
+		return err
+	}
+
+	rec, ok := utils.FindRecord(r.title, db)
+	if !ok {
+		return fmt.Errorf("record '%s' not found", r.title)
+	}
+
+	view, err := newRecordView(rec, r.reveal, db)
+	if err != nil {
+		return err
+	}
+
+	switch r.output {
+	case "json":
+		data, err := json.MarshalIndent(view, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(view)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		fmt.Println(dumpRecord(r.filename, view))
+	}
+
+	return nil
+}
+
+func (r *showAction) flagHandler(fn string) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.StringVar(&(r.filename), "file", fn, "secure password store file")
+		fs.BoolVar(&(r.reveal), "reveal", false, "show the password in clear")
+		fs.StringVar(&(r.output), "o", "text", "the output format - text, json, yaml")
+	}
+}
+
+func (r *showAction) flagPostParser(fs *flag.FlagSet) {
+	if len(fs.Args()) > 0 {
+		r.title = fs.Args()[0]
+	}
+}
+
+// newRecordView returns the view of the record, aliases and shortcuts are resolved
+func newRecordView(rec pwsafe.Record, reveal bool, db pwsafe.DB) (recordView, error) {
+	view := recordView{UUID: uuid.UUID(rec.UUID[:]).String()}
+
+	kind, baseID := rec.EntryType()
+	if kind != pwsafe.NormalEntry {
+		base, ok := db.GetRecordByUUID(baseID)
+		if !ok {
+			return view, pwsafe.DanglingReferenceError{Title: rec.Title, UUID: baseID}
+		}
+		if kind == pwsafe.AliasEntry {
+			view.AliasOf = base.Title
+		} else {
+			view.ShortcutOf = base.Title
+		}
+	}
+
+	rec, err := db.ResolveRecord(rec)
+	if err != nil {
+		return view, err
+	}
+
+	view.Title = rec.Title
+	view.Group = rec.Group
+	view.Username = rec.Username
+	view.Password = passwordMask
+	if reveal {
+		view.Password = rec.Password
+	}
+	view.URL = rec.URL
+	view.Email = rec.Email
+	view.Notes = rec.Notes
+	view.Autotype = rec.Autotype
+	view.RunCommand = rec.RunCommand
+	view.CardExpiration = rec.CreditCardExpiration
+	view.CardNumber = maskUnless(rec.CreditCardNumber, reveal)
+	view.CardVerifValue = maskUnless(rec.CreditCardVerifValue, reveal)
+	view.CardPIN = maskUnless(rec.CreditCardPIN, reveal)
+	view.OTP = rec.HasOTP()
+	view.PasswordPolicyName = rec.PasswordPolicyName
+	view.PasswordHistory = len(rec.PasswordHistoryEntries())
+	view.CreateTime = timeOrNil(rec.CreateTime)
+	view.ModTime = timeOrNil(rec.ModTime)
+	view.PasswordModTime = timeOrNil(rec.PasswordModified())
+	view.PasswordExpiry = timeOrNil(rec.PasswordExpiry)
+
+	return view, nil
+}
+
+// dumpRecord renders the record view as a table of the populated fields
+func dumpRecord(caption string, view recordView) string {
+	table := tablewriter.CreateTable()
+	table.Style = tablewriter.GhostStyle
+	table.AddTitle(caption)
+
+	addRow := func(label, value string) {
+		if value != "" {
+			table.AddRow(label, value)
+		}
+	}
+
+	addRow("TITLE", view.Title)
+	addRow("GROUP", view.Group)
+	addRow("ALIAS OF", view.AliasOf)
+	addRow("SHORTCUT OF", view.ShortcutOf)
+	addRow("USERNAME", view.Username)
+	addRow("PASSWORD", view.Password)
+	addRow("URL", view.URL)
+	addRow("EMAIL", view.Email)
+	addRow("AUTOTYPE", view.Autotype)
+	addRow("RUN COMMAND", view.RunCommand)
+	addRow("CARD NUMBER", view.CardNumber)
+	addRow("CARD EXPIRATION", view.CardExpiration)
+	addRow("CARD CVV", view.CardVerifValue)
+	addRow("CARD PIN", view.CardPIN)
+	if view.OTP {
+		addRow("OTP", "yes")
+	}
+	addRow("PASSWORD POLICY", view.PasswordPolicyName)
+	if view.PasswordHistory > 0 {
+		addRow("PASSWORD HISTORY", fmt.Sprintf("%d entries", view.PasswordHistory))
+	}
+	addRow("CREATED", formatTime(view.CreateTime))
+	addRow("LAST UPDATED", formatTime(view.ModTime))
+	addRow("PASSWORD CHANGED", formatTime(view.PasswordModTime))
+	addRow("PASSWORD EXPIRY", formatTime(view.PasswordExpiry))
+
+	res := table.Render()
+	if view.Notes != "" {
+		res = fmt.Sprintf("%s\n\nNOTES\n%s", res, view.Notes)
+	}
+	return res
+}
+
+// maskUnless returns the mask in place of the secret value, unless it has to be revealed
+func maskUnless(val string, reveal bool) string {
+	if val == "" || reveal {
+		return val
+	}
+	return passwordMask
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() || t.Unix() == 0 {
+		return nil
+	}
+	return &t
+}
@@ -55,6 +55,9 @@ func recordsEqual(record, otherRecord Record, skipTimes bool) (bool, error) {
 			return false, fmt.Errorf("Records don't match, %v != %v", record, otherRecord)
 		}
 	}
+	if !reflect.DeepEqual(record.UnknownFields, otherRecord.UnknownFields) {
+		return false, fmt.Errorf("Records don't match, %v != %v", record, otherRecord)
+	}
 	return true, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"fmt"
 	"io"
 	"sort"
 	"strings"
@@ -20,6 +21,10 @@ type Record struct {
 	AccessTime             time.Time `field:"09"`
 	Autotype               string    `field:"0e"`
 	CreateTime             time.Time `field:"07"`
+	CreditCardExpiration   string    `field:"26"`
+	CreditCardNumber       string    `field:"25"`
+	CreditCardPIN          string    `field:"28"`
+	CreditCardVerifValue   string    `field:"27"`
 	DoubleClickAction      [2]byte   `field:"13"`
 	Email                  string    `field:"14"`
 	Group                  string    `field:"02"`
@@ -36,11 +41,27 @@ type Record struct {
 	RunCommand             string    `field:"12"`
 	ShiftDoubleClickAction [2]byte   `field:"17"`
 	Title                  string    `field:"03"`
+	TOTPLength             byte      `field:"1c"`
+	TOTPStartTime          time.Time `field:"1e"`
+	TOTPTimeStep           byte      `field:"1d"`
+	TwoFactorKey           []byte    `field:"1b"`
 	Username               string    `field:"04"`
 	URL                    string    `field:"0d"`
 	UUID                   [16]byte  `field:"01"`
+	// the fields of newer Password Safe versions, written back as they were read
+	UnknownFields []RawField
 }
 
+// RawField is a record field unknown to this package, kept as its type and value
+type RawField struct {
+	Type byte
+	Data []byte
+}
+
+// PlaceholderPassword is the password of the imported items that have none (i.e. secure notes),
+// since the password is a mandatory field of the records
+const PlaceholderPassword = "(none)"
+
 //V3 The type representing a password safe v3 database
 type V3 struct {
 	CBCIV          [16]byte //Random initial value for CBC
@@ -71,16 +92,26 @@ type V3 struct {
 
 //DB The interface representing the core functionality available for any password database
 type DB interface {
+	AddEmptyGroup(string) error
+	DeleteEmptyGroup(string) error
 	Encrypt(io.Writer) (int, error)
 	Equal(DB) (bool, error)
 	Decrypt(io.Reader, string) (int, error)
 	GetName() string
 	GetRecord(string) (Record, bool)
+	GetRecordByUUID([16]byte) (Record, bool)
 	Groups() []string
+	GroupTree() *GroupNode
 	Identical(DB) (bool, error)
+	ImportRecord(Record) Record
 	List() []string
 	ListByGroup(string) []string
+	MoveGroup(string, string) (int, error)
+	NamedPasswordPolicies() ([]PasswordPolicy, error)
 	NeedsSave() bool
+	RenameRecord(string, string) error
+	ResolveRecord(Record) (Record, error)
+	SetNamedPasswordPolicy(PasswordPolicy) error
 	SetPassword(string) error
 	SetRecord(Record)
 	DeleteRecord(string)
@@ -125,6 +156,16 @@ func (db V3) GetRecord(title string) (Record, bool) {
 	return r, prs
 }
 
+//GetRecordByUUID Returns the record from the db with the given UUID
+func (db V3) GetRecordByUUID(id [16]byte) (Record, bool) {
+	for _, r := range db.Records {
+		if r.UUID == id {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
 //Groups Returns an slice of strings which match all groups used by records in the DB
 func (db V3) Groups() []string {
 	groups := make([]string, 0, len(db.Records))
@@ -181,6 +222,51 @@ func (db V3) ListByGroup(group string) []string {
 	return entries
 }
 
+//RenameRecord Changes the title of a record keeping its UUID, CreateTime and password history
+func (db *V3) RenameRecord(title, newTitle string) error {
+	record, prs := db.Records[title]
+	if !prs {
+		return fmt.Errorf("record '%s' not found", title)
+	}
+	if strings.TrimSpace(newTitle) == "" {
+		return fmt.Errorf("invalid empty record title")
+	}
+	if newTitle == title {
+		return nil
+	}
+	if _, exists := db.Records[newTitle]; exists {
+		return fmt.Errorf("record '%s' already exists", newTitle)
+	}
+
+	now := time.Now()
+	delete(db.Records, title)
+	record.Title = newTitle
+	record.ModTime = now
+	db.Records[newTitle] = record
+	db.LastMod = now
+	return nil
+}
+
+//ImportRecord Adds or replaces a record keeping its times and UUID, used to copy records between dbs.
+//A new UUID is assigned if it is missing or already used by another record, the stored record is returned.
+func (db *V3) ImportRecord(record Record) Record {
+	now := time.Now()
+	if other, prs := db.GetRecordByUUID(record.UUID); record.UUID == [16]byte{} || (prs && other.Title != record.Title) {
+		record.UUID = [16]byte(uuid.NewRandom().Array())
+	}
+	if record.CreateTime.IsZero() {
+		record.CreateTime = now
+	}
+	if record.ModTime.IsZero() {
+		record.ModTime = now
+	}
+
+	db.Records[record.Title] = record
+	db.pruneEmptyGroups(record.Group)
+	db.LastMod = now
+	return record
+}
+
 //SetPassword Sets the password that will be used to encrypt the file on next save
 func (db *V3) SetPassword(pw string) error {
 	// First recalculate the Salt and set iter
@@ -203,8 +289,17 @@ func (db *V3) SetRecord(record Record) {
 		if equal {
 			return
 		}
+		// a password modification time set by the caller (i.e. read from an imported file)
+		// is kept along with the password expiry
+		if record.Password != oldRecord.Password && record.PasswordModTime == oldRecord.PasswordModTime {
+			record.SetPasswordModified(now)
+			record.RenewPasswordExpiry(record.PasswordModified())
+		}
 	} else {
 		record.CreateTime = now
+		if record.PasswordExpiry.IsZero() {
+			record.RenewPasswordExpiry(now)
+		}
 	}
 
 	if record.UUID == [16]byte{} {
@@ -212,6 +307,7 @@ func (db *V3) SetRecord(record Record) {
 	}
 	record.ModTime = now
 	db.Records[record.Title] = record
+	db.pruneEmptyGroups(record.Group)
 	db.LastMod = now
 	// todo add checking of db and record times to the tests
 }
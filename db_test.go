@@ -3,6 +3,7 @@ package pwsafe
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -74,3 +75,61 @@ func TestInvalidFile(t *testing.T) {
 	_, err = OpenPWSafeFile("./notafile", "password")
 	assert.NotNil(t, err)
 }
+
+func TestGetRecordByUUID(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "first", Password: "one"})
+	db.SetRecord(Record{Title: "second", Password: "two"})
+
+	second, _ := db.GetRecord("second")
+	record, exists := db.GetRecordByUUID(second.UUID)
+	assert.Equal(t, true, exists)
+	assert.Equal(t, "second", record.Title)
+
+	_, exists = db.GetRecordByUUID([16]byte{1, 2, 3})
+	assert.Equal(t, false, exists)
+}
+
+func TestRenameRecord(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "first", Password: "one", PasswordHistory: "history"})
+	db.SetRecord(Record{Title: "second", Password: "two"})
+	first, _ := db.GetRecord("first")
+
+	assert.NotNil(t, db.RenameRecord("missing", "other"))
+	assert.NotNil(t, db.RenameRecord("first", "second"))
+	assert.NotNil(t, db.RenameRecord("first", " "))
+
+	assert.Nil(t, db.RenameRecord("first", "renamed"))
+	_, exists := db.GetRecord("first")
+	assert.Equal(t, false, exists)
+
+	renamed, exists := db.GetRecord("renamed")
+	assert.Equal(t, true, exists)
+	assert.Equal(t, "renamed", renamed.Title)
+	assert.Equal(t, first.UUID, renamed.UUID)
+	assert.Equal(t, first.CreateTime, renamed.CreateTime)
+	assert.Equal(t, "history", renamed.PasswordHistory)
+	assert.Equal(t, []string{"renamed", "second"}, db.List())
+}
+
+func TestImportRecord(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "first", Password: "one"})
+	first, _ := db.GetRecord("first")
+
+	created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := db.ImportRecord(Record{Title: "second", Password: "two", UUID: first.UUID, CreateTime: created, ModTime: created})
+	assert.NotEqual(t, first.UUID, stored.UUID)
+	assert.Equal(t, created, stored.CreateTime)
+	assert.Equal(t, created, stored.ModTime)
+
+	id := [16]byte{1, 2, 3}
+	stored = db.ImportRecord(Record{Title: "third", Password: "three", UUID: id})
+	assert.Equal(t, id, stored.UUID)
+	assert.Equal(t, false, stored.CreateTime.IsZero())
+
+	record, exists := db.GetRecordByUUID(id)
+	assert.Equal(t, true, exists)
+	assert.Equal(t, "third", record.Title)
+}
@@ -96,7 +96,7 @@ func (db *V3) Decrypt(reader io.Reader, passwd string) (int, error) {
 	}
 
 	//UnMarshal the decrypted DB, first the header
-	hdrSize, headerHMACData, err := unmarshalRecord(decryptedDB, mapByFieldTag(db))
+	hdrSize, headerHMACData, err := unmarshalRecord(decryptedDB, mapByFieldTag(db), nil)
 	if err != nil {
 		return bytesRead, errors.New("Error parsing the unencrypted header - " + err.Error())
 	}
@@ -177,6 +177,20 @@ func setField(field *structs.Field, data []byte) {
 			copy(farray[:], data)
 			field.Set(farray)
 		}
+	case "uint8":
+		if len(data) > 0 {
+			field.Set(data[0])
+		}
+	case "slice":
+		// repeatable fields (i.e. the header empty groups) collect all their values
+		if multi, ok := field.Value().([]string); ok {
+			field.Set(append(multi, string(data)))
+			return
+		}
+		err := field.Set(data)
+		if err != nil {
+			panic(err)
+		}
 
 	default:
 		err := field.Set(data)
@@ -195,7 +209,7 @@ func (db *V3) unmarshalRecords(records []byte) (int, []byte, error) {
 	for recordStart < len(records) {
 		record := &Record{}
 		recordFieldMap := mapByFieldTag(record)
-		recordLength, recordData, err := unmarshalRecord(records[recordStart:], recordFieldMap)
+		recordLength, recordData, err := unmarshalRecord(records[recordStart:], recordFieldMap, &record.UnknownFields)
 		db.Records[record.Title] = *record
 		if err != nil {
 			return recordStart, hmacData, errors.New("Error parsing record - " + err.Error())
@@ -213,7 +227,8 @@ func (db *V3) unmarshalRecords(records []byte) (int, []byte, error) {
 // UnMarshal a single record from the given records []byte, writing to fields in recordFieldMap, return record size, raw record Data and error/nil
 // Individual records stop with an END field
 // This function is used both to UnMarshal the header and individual records in the DB
-func unmarshalRecord(records []byte, recordFieldMap map[byte]*structs.Field) (int, []byte, error) {
+// The fields not in recordFieldMap are collected in unknown, they are an error when it is nil
+func unmarshalRecord(records []byte, recordFieldMap map[byte]*structs.Field, unknown *[]RawField) (int, []byte, error) {
 	var rdata []byte
 	fieldStart := 0
 	for {
@@ -236,6 +251,8 @@ func unmarshalRecord(records []byte, recordFieldMap map[byte]*structs.Field) (in
 			setField(field, data)
 		} else if btype == 0xff { //end
 			return fieldStart, rdata, nil
+		} else if unknown != nil {
+			*unknown = append(*unknown, RawField{Type: btype, Data: data})
 		} else {
 			return fieldStart, rdata, fmt.Errorf("Encountered unknown Record Field type - %v", btype)
 		}
@@ -54,7 +54,7 @@ func (db *V3) Encrypt(writer io.Writer) (int, error) {
 	//ordered := structs.Fields(db)
 	//headerFields := append(ordered[:len(ordered)-2], ordered[len(ordered)-1])
 
-	headerBytes, headerValues := marshalRecord(headerFields)
+	headerBytes, headerValues := marshalRecord(headerFields, nil)
 	unencryptedBytes = append(unencryptedBytes, headerBytes...)
 
 	recordBytes, recordValues := db.marshalRecords()
@@ -104,12 +104,28 @@ func getFieldBytes(field *structs.Field) (fbytes []byte) {
 			farray := field.Value().([16]byte)
 			fbytes = farray[:]
 		}
+	case "uint8":
+		fbytes = []byte{field.Value().(byte)}
 	default:
 		fbytes = field.Value().([]byte)
 	}
 	return fbytes
 }
 
+// getFieldValues returns the []byte representations of the field data,
+// repeatable fields (i.e. the header empty groups) have one for each value
+func getFieldValues(field *structs.Field) [][]byte {
+	if multi, ok := field.Value().([]string); ok {
+		values := make([][]byte, 0, len(multi))
+		for _, v := range multi {
+			values = append(values, []byte(v))
+		}
+		return values
+	}
+
+	return [][]byte{getFieldBytes(field)}
+}
+
 // intToBytes Converts an int to byte array
 func intToBytes(num int) []byte {
 	intBytes := make([]byte, 4)
@@ -119,7 +135,24 @@ func intToBytes(num int) []byte {
 
 // marshalHeader return the binary format for the record as specified in the spec and the header values used for hmac calculations
 // This function is used both to Marshal the header and individual records in the DB
-func marshalRecord(fields []*structs.Field) (record []byte, totalDataBytes []byte) {
+func marshalRecord(fields []*structs.Field, unknown []RawField) (record []byte, totalDataBytes []byte) {
+	appendField := func(fieldType byte, dataBytes []byte) {
+		totalDataBytes = append(totalDataBytes, dataBytes...)
+
+		// Each record is the length, type and data
+		record = append(record, intToBytes(len(dataBytes))...)
+		record = append(record, fieldType)
+
+		// Add in the data
+		record = append(record, dataBytes...)
+
+		// if total written bytes doesn't match twofish.BlockSize fill remaining bytes with pseudo random values
+		usedBlockSpace := (len(dataBytes) + 5) % twofish.BlockSize
+		if usedBlockSpace != 0 {
+			record = append(record, pseudoRandmonBytes(twofish.BlockSize-usedBlockSpace)...)
+		}
+	}
+
 	for _, field := range fields {
 		fieldTypeStr := field.Tag("field")
 		if fieldTypeStr == "" || field.IsZero() {
@@ -129,23 +162,15 @@ func marshalRecord(fields []*structs.Field) (record []byte, totalDataBytes []byt
 			if err != nil {
 				panic(fmt.Sprintf("Invalid field type in struct tag for %s\n\t%v", field.Name(), err))
 			}
-			dataBytes := getFieldBytes(field)
-			totalDataBytes = append(totalDataBytes, dataBytes...)
-
-			// Each record is the length, type and data
-			record = append(record, intToBytes(len(dataBytes))...)
-			record = append(record, fieldType[0])
-
-			// Add in the data
-			record = append(record, dataBytes...)
-
-			// if total written bytes doesn't match twofish.BlockSize fill remaining bytes with pseudo random values
-			usedBlockSpace := (len(dataBytes) + 5) % twofish.BlockSize
-			if usedBlockSpace != 0 {
-				record = append(record, pseudoRandmonBytes(twofish.BlockSize-usedBlockSpace)...)
+			for _, dataBytes := range getFieldValues(field) {
+				appendField(fieldType[0], dataBytes)
 			}
 		}
 	}
+	// the fields unknown to this package are written back as they were read
+	for _, el := range unknown {
+		appendField(el.Type, el.Data)
+	}
 
 	//finish with the end of record
 	record = append(record, []byte{0, 0, 0, 0}...)
@@ -175,7 +200,7 @@ func (db *V3) marshalRecords() (records []byte, dataBytes []byte) {
 		}
 
 		// finally call marshalRecord for this record
-		rBytes, hmacBytes := marshalRecord(structs.Fields(record))
+		rBytes, hmacBytes := marshalRecord(structs.Fields(record), record.UnknownFields)
 		records = append(records, rBytes...)
 		dataBytes = append(dataBytes, hmacBytes...)
 	}
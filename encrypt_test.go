@@ -1,6 +1,7 @@
 package pwsafe
 
 import (
+	"bytes"
 	"os"
 	"testing"
 
@@ -65,3 +66,23 @@ func TestNewV3(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, true, equal)
 }
+
+// TestUnknownRecordFields the record fields of newer versions are written back as they were read
+func TestUnknownRecordFields(t *testing.T) {
+	db := NewV3("", "password")
+	unknown := []RawField{{Type: 0x40, Data: []byte("from the future")}, {Type: 0x41, Data: []byte{}}}
+	db.SetRecord(Record{Title: "future", Password: "s3cr3t", UnknownFields: unknown})
+
+	var buf bytes.Buffer
+	_, err := db.Encrypt(&buf)
+	assert.Nil(t, err)
+
+	var read V3
+	_, err = read.Decrypt(&buf, "password")
+	if assert.Nil(t, err) {
+		rec, ok := read.GetRecord("future")
+		assert.True(t, ok)
+		assert.Equal(t, "s3cr3t", rec.Password)
+		assert.Equal(t, unknown, rec.UnknownFields)
+	}
+}
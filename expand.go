@@ -0,0 +1,206 @@
+package pwsafe
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ReferenceCycleError is returned when a reference ends up referencing itself
+type ReferenceCycleError struct {
+	Reference string
+}
+
+func (e ReferenceCycleError) Error() string {
+	return fmt.Sprintf("reference cycle detected expanding [[%s]]", e.Reference)
+}
+
+// Field Returns the content of the field with the given name
+// (title, group, user, pass, url, notes, email, autotype, runcmd)
+func (r Record) Field(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "title":
+		return r.Title, nil
+	case "group", "category":
+		return r.Group, nil
+	case "user", "username":
+		return r.Username, nil
+	case "pass", "password":
+		return r.Password, nil
+	case "url":
+		return r.URL, nil
+	case "notes":
+		return r.Notes, nil
+	case "email":
+		return r.Email, nil
+	case "autotype":
+		return r.Autotype, nil
+	case "runcmd", "runcommand":
+		return r.RunCommand, nil
+	}
+
+	return "", fmt.Errorf("unknown field '%s'", name)
+}
+
+// SplitReference Returns the title and the field of a 'Title:field' reference. The text after
+// the last ':' is the field only if it is a known field name, so that the titles can hold ':'
+// (i.e. 'host:8080'). When the field is omitted it is the password.
+func SplitReference(ref string) (string, string) {
+	if idx := strings.LastIndex(ref, ":"); idx > 0 {
+		if _, err := (Record{}).Field(ref[idx+1:]); err == nil {
+			return ref[:idx], ref[idx+1:]
+		}
+	}
+	return ref, "pass"
+}
+
+// templateFields are the fields holding text with references, the others
+// (i.e. the password) are secrets used as they are
+var templateFields = map[string]bool{
+	"notes": true, "autotype": true, "runcmd": true, "runcommand": true,
+}
+
+// IsTemplateField Tells if the references in the named field are expanded
+func IsTemplateField(name string) bool {
+	return templateFields[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// Expand Returns the text with the references expanded:
+//
+//	\u username, \p password, \g group, \i title, \l URL, \m email, \o notes of this record
+//	[[Title:field]] the field of another record ([[Title]] is its password)
+//
+// The referenced template fields (notes, autotype, runcmd) are expanded too, a ReferenceCycleError
+// is returned on cycles. The other fields are used as they are, a password can hold '[['.
+func (r Record) Expand(text string, db DB) (string, error) {
+	e := expander{db: db, stack: make(map[string]bool)}
+	return e.expand(&r, text)
+}
+
+// ExpandReferences Returns the text with the [[Title:field]] references expanded
+func ExpandReferences(text string, db DB) (string, error) {
+	e := expander{db: db, stack: make(map[string]bool)}
+	return e.expand(nil, text)
+}
+
+// expander keeps track of the references being expanded to detect cycles
+type expander struct {
+	db    DB
+	stack map[string]bool
+}
+
+// expand replaces the references in text, the field codes are expanded only if rec is not nil
+func (e *expander) expand(rec *Record, text string) (string, error) {
+	if !strings.Contains(text, "[[") && (rec == nil || !strings.Contains(text, `\`)) {
+		return text, nil
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if strings.HasPrefix(text[i:], "[[") {
+			end := strings.Index(text[i+2:], "]]")
+			if end >= 0 {
+				val, err := e.reference(text[i+2 : i+2+end])
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(val)
+				i += end + 3
+				continue
+			}
+		}
+
+		if rec != nil && text[i] == '\\' && i+1 < len(text) {
+			field := ""
+			switch text[i+1] {
+			case 'u':
+				field = "user"
+			case 'p':
+				field = "pass"
+			case 'g':
+				field = "group"
+			case 'i':
+				field = "title"
+			case 'l':
+				field = "url"
+			case 'm':
+				field = "email"
+			case 'o':
+				field = "notes"
+			case '\\':
+				out.WriteByte('\\')
+				i++
+				continue
+			}
+
+			if field != "" {
+				val, err := e.field(*rec, field)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(val)
+				i++
+				continue
+			}
+		}
+
+		out.WriteByte(text[i])
+	}
+
+	return out.String(), nil
+}
+
+// reference resolves a 'Title:field' (or 'Title' or 'uuid') reference
+func (e *expander) reference(ref string) (string, error) {
+	title, field := SplitReference(ref)
+
+	rec, ok := e.find(title)
+	if !ok {
+		return "", fmt.Errorf("reference [[%s]] can not be resolved, record not found", ref)
+	}
+
+	return e.field(rec, field)
+}
+
+// field returns the content of the record field, with its references expanded if it is a template
+func (e *expander) field(rec Record, field string) (string, error) {
+	key := strings.ToLower(fmt.Sprintf("%x:%s", rec.UUID, field))
+	if e.stack[key] {
+		return "", ReferenceCycleError{Reference: fmt.Sprintf("%s:%s", rec.Title, field)}
+	}
+	e.stack[key] = true
+	defer delete(e.stack, key)
+
+	resolved, err := e.db.ResolveRecord(rec)
+	if err != nil {
+		return "", err
+	}
+
+	val, err := resolved.Field(field)
+	if err != nil || !IsTemplateField(field) {
+		return val, err
+	}
+
+	return e.expand(nil, val)
+}
+
+// find looks up a record by UUID (32 hex chars) or by title (case insensitive)
+func (e *expander) find(title string) (Record, bool) {
+	if len(title) == 32 {
+		if b, err := hex.DecodeString(title); err == nil {
+			var id [16]byte
+			copy(id[:], b)
+			if rec, ok := e.db.GetRecordByUUID(id); ok {
+				return rec, true
+			}
+		}
+	}
+
+	for _, t := range e.db.List() {
+		if strings.EqualFold(title, t) {
+			return e.db.GetRecord(t)
+		}
+	}
+
+	return Record{}, false
+}
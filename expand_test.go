@@ -0,0 +1,90 @@
+package pwsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "db", Username: "admin", Password: "s3cr3t"})
+	db.SetRecord(Record{Title: "app", Username: "john", Password: "pw", URL: "http://app.com",
+		RunCommand: `ssh \u@host --pass [[db:pass]] \\u`, Notes: "db user is [[DB:user]]"})
+	base, _ := db.GetRecord("db")
+	db.SetRecord(Record{Title: "alias", Password: AliasReference(base)})
+	db.SetRecord(Record{Title: "loop a", Password: "a", Notes: "[[loop b:notes]]"})
+	db.SetRecord(Record{Title: "loop b", Password: "b", Notes: "[[loop a:notes]]"})
+
+	app, _ := db.GetRecord("app")
+	expanded, err := app.Expand(app.RunCommand, db)
+	assert.Nil(t, err)
+	assert.Equal(t, `ssh john@host --pass s3cr3t \u`, expanded)
+
+	expanded, err = app.Expand(`\o at \l`, db)
+	assert.Nil(t, err)
+	assert.Equal(t, "db user is admin at http://app.com", expanded)
+
+	// references by uuid and to aliases, no field means password
+	expanded, err = ExpandReferences("[[alias]] [["+hexUUID(base)+"]]", db)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t s3cr3t", expanded)
+
+	_, err = ExpandReferences("[[missing:pass]]", db)
+	assert.NotNil(t, err)
+
+	loop, _ := db.GetRecord("loop a")
+	_, err = loop.Expand(loop.Notes, db)
+	assert.IsType(t, ReferenceCycleError{}, err)
+}
+
+func TestExpandLiteralPassword(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "literal", Username: "[[x]]", Password: "a[[x]]b"})
+	db.SetRecord(Record{Title: "app", Password: "pw", Notes: "pass: [[literal:pass]]", RunCommand: `run \o`})
+
+	expanded, err := ExpandReferences("[[literal]] [[literal:user]]", db)
+	assert.Nil(t, err)
+	assert.Equal(t, "a[[x]]b [[x]]", expanded)
+
+	app, _ := db.GetRecord("app")
+	expanded, err = app.Expand(app.RunCommand, db)
+	assert.Nil(t, err)
+	assert.Equal(t, "run pass: a[[x]]b", expanded)
+
+	lit, _ := db.GetRecord("literal")
+	expanded, err = lit.Expand(`\p`, db)
+	assert.Nil(t, err)
+	assert.Equal(t, "a[[x]]b", expanded)
+
+	assert.True(t, IsTemplateField("Notes"))
+	assert.False(t, IsTemplateField("pass"))
+}
+
+func TestSplitReference(t *testing.T) {
+	for _, el := range []struct {
+		ref, title, field string
+	}{
+		{"db", "db", "pass"},
+		{"db:user", "db", "user"},
+		{"Infra/db:Notes", "Infra/db", "Notes"},
+		{"host:8080", "host:8080", "pass"},
+		{"host:8080:url", "host:8080", "url"},
+		{"a:b:c", "a:b:c", "pass"},
+		{":user", ":user", "pass"},
+	} {
+		title, field := SplitReference(el.ref)
+		assert.Equal(t, el.title, title, el.ref)
+		assert.Equal(t, el.field, field, el.ref)
+	}
+
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "host:8080", Username: "admin", Password: "s3cr3t"})
+	expanded, err := ExpandReferences("[[host:8080]] [[host:8080:user]]", db)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t admin", expanded)
+}
+
+func hexUUID(r Record) string {
+	return AliasReference(r)[2:34]
+}
@@ -0,0 +1,47 @@
+package pwsafe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// maxPasswordExpiryDays is the maximum password expiry interval allowed by the format
+const maxPasswordExpiryDays = 3650
+
+// PasswordExpiryDays Returns the days the password is valid after it changes, 0 if it does not expire periodically
+func (r Record) PasswordExpiryDays() int {
+	return int(binary.LittleEndian.Uint32(r.PasswordExpiryInterval[:]))
+}
+
+// SetPasswordExpiryDays Sets the days the password is valid after it changes (from 1 to 3650), 0 to disable it.
+// The password expiry is not changed, see RenewPasswordExpiry.
+func (r *Record) SetPasswordExpiryDays(days int) error {
+	if days < 0 || days > maxPasswordExpiryDays {
+		return fmt.Errorf("invalid password expiry interval %d days - accepted values are from 1 to %d", days, maxPasswordExpiryDays)
+	}
+	binary.LittleEndian.PutUint32(r.PasswordExpiryInterval[:], uint32(days))
+	return nil
+}
+
+// RenewPasswordExpiry Sets the password expiry to the expiry interval after the password change,
+// nothing is done if the record has no interval
+func (r *Record) RenewPasswordExpiry(changed time.Time) {
+	if days := r.PasswordExpiryDays(); days > 0 {
+		r.PasswordExpiry = changed.AddDate(0, 0, days)
+	}
+}
+
+// PasswordExpired Tells if the password expiry is set and not after the specified time
+func (r Record) PasswordExpired(t time.Time) bool {
+	return !r.PasswordExpiry.IsZero() && !r.PasswordExpiry.After(t)
+}
+
+// LastPasswordChange Returns when the password was last changed, the creation time
+// when it never changed since the password modification time is set only then
+func (r Record) LastPasswordChange() time.Time {
+	if t := r.PasswordModified(); !t.IsZero() {
+		return t
+	}
+	return r.CreateTime
+}
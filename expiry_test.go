@@ -0,0 +1,92 @@
+package pwsafe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordExpiryDays(t *testing.T) {
+	var rec Record
+	assert.Equal(t, 0, rec.PasswordExpiryDays())
+
+	assert.Nil(t, rec.SetPasswordExpiryDays(90))
+	assert.Equal(t, [4]byte{90, 0, 0, 0}, rec.PasswordExpiryInterval)
+	assert.Equal(t, 90, rec.PasswordExpiryDays())
+
+	assert.Nil(t, rec.SetPasswordExpiryDays(3650))
+	assert.Equal(t, 3650, rec.PasswordExpiryDays())
+
+	assert.NotNil(t, rec.SetPasswordExpiryDays(3651))
+	assert.NotNil(t, rec.SetPasswordExpiryDays(-1))
+	assert.Equal(t, 3650, rec.PasswordExpiryDays())
+}
+
+func TestRenewPasswordExpiry(t *testing.T) {
+	changed := time.Date(2020, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	var rec Record
+	rec.RenewPasswordExpiry(changed)
+	assert.True(t, rec.PasswordExpiry.IsZero())
+
+	rec.SetPasswordExpiryDays(30)
+	rec.RenewPasswordExpiry(changed)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC), rec.PasswordExpiry)
+
+	assert.False(t, rec.PasswordExpired(changed))
+	assert.True(t, rec.PasswordExpired(rec.PasswordExpiry))
+	assert.False(t, Record{}.PasswordExpired(changed))
+}
+
+func TestLastPasswordChange(t *testing.T) {
+	rec := Record{CreateTime: time.Unix(1500000000, 0)}
+	assert.Equal(t, rec.CreateTime, rec.LastPasswordChange())
+
+	rec.SetPasswordModified(time.Unix(1600000000, 0))
+	assert.Equal(t, time.Unix(1600000000, 0), rec.LastPasswordChange())
+}
+
+func TestSetRecordRenewsPasswordExpiry(t *testing.T) {
+	db := NewV3("", "password")
+	rec := Record{Title: "expiring", Password: "first"}
+	rec.SetPasswordExpiryDays(10)
+	db.SetRecord(rec)
+
+	rec, _ = db.GetRecord("expiring")
+	assert.Equal(t, rec.CreateTime.AddDate(0, 0, 10), rec.PasswordExpiry)
+	assert.True(t, rec.PasswordModified().IsZero())
+
+	rec.PasswordExpiry = time.Unix(1500000000, 0)
+	rec.Notes = "the password is unchanged"
+	db.SetRecord(rec)
+	rec, _ = db.GetRecord("expiring")
+	assert.Equal(t, time.Unix(1500000000, 0), rec.PasswordExpiry)
+
+	rec.Password = "second"
+	db.SetRecord(rec)
+	rec, _ = db.GetRecord("expiring")
+	assert.False(t, rec.PasswordModified().IsZero())
+	assert.Equal(t, rec.PasswordModified().AddDate(0, 0, 10), rec.PasswordExpiry)
+}
+
+func TestSetRecordKeepsPasswordTimes(t *testing.T) {
+	db := NewV3("", "password")
+	expiry := time.Unix(1700000000, 0)
+	rec := Record{Title: "imported", Password: "first", PasswordExpiry: expiry}
+	rec.SetPasswordExpiryDays(10)
+	db.SetRecord(rec)
+
+	rec, _ = db.GetRecord("imported")
+	assert.Equal(t, expiry, rec.PasswordExpiry)
+
+	// as an import overwriting the record with the times of the imported file
+	rec.Password = "second"
+	rec.SetPasswordModified(time.Unix(1600000000, 0))
+	rec.PasswordExpiry = time.Unix(1600864000, 0)
+	db.SetRecord(rec)
+
+	rec, _ = db.GetRecord("imported")
+	assert.Equal(t, time.Unix(1600000000, 0), rec.PasswordModified())
+	assert.Equal(t, time.Unix(1600864000, 0), rec.PasswordExpiry)
+}
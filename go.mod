@@ -11,5 +11,6 @@ require (
 	github.com/lucasepe/tablewriter v0.0.0-20190604112110-b600b1229855
 	github.com/pborman/uuid v1.2.0
 	github.com/stretchr/testify v1.3.0
-	golang.org/x/crypto v0.0.0-20190911031432-227b76d455e7
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	gopkg.in/yaml.v3 v3.0.1
 )
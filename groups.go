@@ -0,0 +1,262 @@
+package pwsafe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GroupSeparator separates the levels of a group path (i.e. "Bank.Personal"),
+// a dot that is part of a group name is escaped with a backslash
+const GroupSeparator = '.'
+
+// GroupNode is a group in the groups hierarchy
+type GroupNode struct {
+	Name    string       // the name of the group (unescaped)
+	Path    string       // the full path of the group, empty for the root
+	Groups  []*GroupNode // the sub groups sorted by name
+	Records []string     // the titles of the records in this group
+}
+
+// SplitGroup Returns the unescaped names of the levels of a group path
+func SplitGroup(group string) []string {
+	if group == "" {
+		return nil
+	}
+
+	var names []string
+	var name strings.Builder
+	for i := 0; i < len(group); i++ {
+		switch {
+		case group[i] == '\\' && i+1 < len(group) && group[i+1] == GroupSeparator:
+			name.WriteByte(GroupSeparator)
+			i++
+		case group[i] == GroupSeparator:
+			names = append(names, name.String())
+			name.Reset()
+		default:
+			name.WriteByte(group[i])
+		}
+	}
+	return append(names, name.String())
+}
+
+// JoinGroup Returns the group path for the given level names, escaping their dots
+func JoinGroup(names ...string) string {
+	escaped := make([]string, len(names))
+	for i, n := range names {
+		escaped[i] = strings.Replace(n, string(GroupSeparator), `\`+string(GroupSeparator), -1)
+	}
+	return strings.Join(escaped, string(GroupSeparator))
+}
+
+// ParentGroup Returns the path of the parent group, empty for top level groups
+func ParentGroup(group string) string {
+	names := SplitGroup(group)
+	if len(names) < 2 {
+		return ""
+	}
+	return JoinGroup(names[:len(names)-1]...)
+}
+
+// InGroup Returns true if the group is the parent group or one of its sub groups
+func InGroup(group, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	return group == parent || strings.HasPrefix(group, parent+string(GroupSeparator))
+}
+
+// Walk Visits the node and its sub groups depth first, stops at the first error
+func (n *GroupNode) Walk(fn func(node *GroupNode, depth int) error) error {
+	return n.walk(fn, 0)
+}
+
+func (n *GroupNode) walk(fn func(node *GroupNode, depth int) error, depth int) error {
+	if err := fn(n, depth); err != nil {
+		return err
+	}
+	for _, child := range n.Groups {
+		if err := child.walk(fn, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find Returns the node of the given group path, nil if it doesn't exist
+func (n *GroupNode) Find(group string) *GroupNode {
+	node := n
+	for _, name := range SplitGroup(group) {
+		var next *GroupNode
+		for _, child := range node.Groups {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// RecordCount Returns the number of records in the group and its sub groups
+func (n *GroupNode) RecordCount() int {
+	count := 0
+	n.Walk(func(node *GroupNode, depth int) error {
+		count += len(node.Records)
+		return nil
+	})
+	return count
+}
+
+// child returns the sub group with the given name creating it if needed
+func (n *GroupNode) child(name string) *GroupNode {
+	for _, c := range n.Groups {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	names := append(SplitGroup(n.Path), name)
+	c := &GroupNode{Name: name, Path: JoinGroup(names...)}
+	n.Groups = append(n.Groups, c)
+	return c
+}
+
+// ensure returns the node for the group path creating the missing levels
+func (n *GroupNode) ensure(group string) *GroupNode {
+	node := n
+	for _, name := range SplitGroup(group) {
+		node = node.child(name)
+	}
+	return node
+}
+
+func (n *GroupNode) sort() {
+	sort.Strings(n.Records)
+	sort.Slice(n.Groups, func(i, j int) bool {
+		return n.Groups[i].Name < n.Groups[j].Name
+	})
+	for _, c := range n.Groups {
+		c.sort()
+	}
+}
+
+// GroupTree Returns the hierarchy of the groups, empty groups included
+func (db V3) GroupTree() *GroupNode {
+	root := &GroupNode{}
+	for title, rec := range db.Records {
+		node := root.ensure(rec.Group)
+		node.Records = append(node.Records, title)
+	}
+	for _, g := range db.EmptyGroups {
+		root.ensure(g)
+	}
+	root.sort()
+	return root
+}
+
+// AddEmptyGroup Creates a new group with no records
+func (db *V3) AddEmptyGroup(group string) error {
+	if strings.TrimSpace(group) == "" {
+		return fmt.Errorf("invalid empty group name")
+	}
+	if db.GroupTree().Find(group) != nil {
+		return fmt.Errorf("group '%s' already exists", group)
+	}
+
+	db.pruneEmptyGroups(ParentGroup(group))
+	db.EmptyGroups = append(db.EmptyGroups, group)
+	sort.Strings(db.EmptyGroups)
+	db.LastMod = time.Now()
+	return nil
+}
+
+// DeleteEmptyGroup Removes a group (and its sub groups) that holds no records
+func (db *V3) DeleteEmptyGroup(group string) error {
+	tree := db.GroupTree()
+	node := tree.Find(group)
+	if node == nil || group == "" {
+		return fmt.Errorf("group '%s' not found", group)
+	}
+	if node.RecordCount() > 0 {
+		return fmt.Errorf("group '%s' is not empty", group)
+	}
+
+	groups := make([]string, 0, len(db.EmptyGroups))
+	for _, g := range db.EmptyGroups {
+		if !InGroup(g, group) {
+			groups = append(groups, g)
+		}
+	}
+	db.EmptyGroups = groups
+
+	// keep the parent group, as removing a directory doesn't remove its parent
+	if parent := ParentGroup(group); parent != "" {
+		if p := tree.Find(parent); p != nil && len(p.Records) == 0 && len(p.Groups) == 1 {
+			db.EmptyGroups = append(db.EmptyGroups, parent)
+			sort.Strings(db.EmptyGroups)
+		}
+	}
+
+	db.LastMod = time.Now()
+	return nil
+}
+
+// MoveGroup Renames a group moving all its records and sub groups, returns the number of moved records
+func (db *V3) MoveGroup(from, to string) (int, error) {
+	tree := db.GroupTree()
+	if from == "" || tree.Find(from) == nil {
+		return 0, fmt.Errorf("group '%s' not found", from)
+	}
+	if strings.TrimSpace(to) == "" {
+		return 0, fmt.Errorf("invalid empty group name")
+	}
+	if InGroup(to, from) {
+		return 0, fmt.Errorf("can not move group '%s' inside itself", from)
+	}
+	if tree.Find(to) != nil {
+		return 0, fmt.Errorf("group '%s' already exists", to)
+	}
+
+	moved := 0
+	for _, title := range db.List() {
+		rec := db.Records[title]
+		if InGroup(rec.Group, from) {
+			rec.Group = to + rec.Group[len(from):]
+			db.SetRecord(rec)
+			moved++
+		}
+	}
+
+	for i, g := range db.EmptyGroups {
+		if InGroup(g, from) {
+			db.EmptyGroups[i] = to + g[len(from):]
+		}
+	}
+	db.pruneEmptyGroups(ParentGroup(to))
+	sort.Strings(db.EmptyGroups)
+
+	db.LastMod = time.Now()
+	return moved, nil
+}
+
+// pruneEmptyGroups removes the group and its parents from the empty groups, since they now have content
+func (db *V3) pruneEmptyGroups(group string) {
+	if len(db.EmptyGroups) == 0 {
+		return
+	}
+
+	groups := make([]string, 0, len(db.EmptyGroups))
+	for _, g := range db.EmptyGroups {
+		if !InGroup(group, g) || g == "" {
+			groups = append(groups, g)
+		}
+	}
+	db.EmptyGroups = groups
+}
@@ -0,0 +1,89 @@
+package pwsafe
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitJoinGroup(t *testing.T) {
+	assert.Equal(t, []string{"Bank", "Personal"}, SplitGroup("Bank.Personal"))
+	assert.Equal(t, []string{"Web", "www.site.com"}, SplitGroup(`Web.www\.site\.com`))
+	assert.Equal(t, `Web.www\.site\.com`, JoinGroup("Web", "www.site.com"))
+	assert.Equal(t, "Bank", ParentGroup("Bank.Personal"))
+	assert.Equal(t, "", ParentGroup("Bank"))
+	assert.Equal(t, true, InGroup("Bank.Personal", "Bank"))
+	assert.Equal(t, false, InGroup("Banking", "Bank"))
+}
+
+func TestGroupTree(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "checking", Group: "Bank.Personal", Password: "1"})
+	db.SetRecord(Record{Title: "savings", Group: "Bank.Personal", Password: "2"})
+	db.SetRecord(Record{Title: "payroll", Group: "Bank.Work", Password: "3"})
+	db.SetRecord(Record{Title: "root entry", Password: "4"})
+	assert.Nil(t, db.AddEmptyGroup("Infra.Prod"))
+	assert.NotNil(t, db.AddEmptyGroup("Bank.Work"))
+
+	tree := db.GroupTree()
+	assert.Equal(t, []string{"root entry"}, tree.Records)
+	assert.Equal(t, 2, len(tree.Groups))
+	assert.Equal(t, "Bank", tree.Groups[0].Name)
+	assert.Equal(t, []string{"checking", "savings"}, tree.Find("Bank.Personal").Records)
+	assert.NotNil(t, tree.Find("Infra.Prod"))
+	assert.Equal(t, 3, tree.Find("Bank").RecordCount())
+
+	var paths []string
+	tree.Walk(func(node *GroupNode, depth int) error {
+		paths = append(paths, node.Path)
+		return nil
+	})
+	assert.Equal(t, []string{"", "Bank", "Bank.Personal", "Bank.Work", "Infra", "Infra.Prod"}, paths)
+
+	// adding a record to an empty group makes it a regular group
+	db.SetRecord(Record{Title: "server", Group: "Infra.Prod", Password: "5"})
+	assert.Equal(t, 0, len(db.EmptyGroups))
+}
+
+func TestMoveAndDeleteGroup(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "checking", Group: "Bank.Personal", Password: "1"})
+	db.SetRecord(Record{Title: "other", Group: "Banking", Password: "2"})
+	assert.Nil(t, db.AddEmptyGroup("Bank.Personal.Old"))
+
+	moved, err := db.MoveGroup("Bank", "Finance.Bank")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, moved)
+	record, _ := db.GetRecord("checking")
+	assert.Equal(t, "Finance.Bank.Personal", record.Group)
+	record, _ = db.GetRecord("other")
+	assert.Equal(t, "Banking", record.Group)
+	assert.Equal(t, []string{"Finance.Bank.Personal.Old"}, db.EmptyGroups)
+
+	_, err = db.MoveGroup("Finance", "Finance.Sub")
+	assert.NotNil(t, err)
+	_, err = db.MoveGroup("Missing", "Other")
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, db.DeleteEmptyGroup("Finance.Bank"))
+	assert.Nil(t, db.DeleteEmptyGroup("Finance.Bank.Personal.Old"))
+	assert.Equal(t, 0, len(db.EmptyGroups))
+}
+
+// TestEmptyGroupsRoundTrip verify the repeated empty groups header field survives encryption and decryption
+func TestEmptyGroupsRoundTrip(t *testing.T) {
+	db := NewV3("", "password")
+	db.SetRecord(Record{Title: "entry", Password: "password"})
+	assert.Nil(t, db.AddEmptyGroup("Empty.One"))
+	assert.Nil(t, db.AddEmptyGroup("Empty Two"))
+
+	var buf bytes.Buffer
+	_, err := db.Encrypt(&buf)
+	assert.Nil(t, err)
+
+	var readDB V3
+	_, err = readDB.Decrypt(&buf, "password")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Empty Two", "Empty.One"}, readDB.EmptyGroups)
+}
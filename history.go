@@ -0,0 +1,123 @@
+package pwsafe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPasswordHistory is the maximum number of entries of the password history field
+const maxPasswordHistory = 255
+
+// PasswordHistoryEntry is a previous password of a record
+type PasswordHistoryEntry struct {
+	Time     time.Time // when the password was replaced
+	Password string
+}
+
+// PasswordHistoryEntries Returns the previous passwords of the record, oldest first.
+// The history field is "fmmnn" (enabled flag, max and number of entries as hex)
+// followed by "TTTTTTTTLLLL<password>" for each entry, a malformed field returns the entries parsed so far.
+func (r Record) PasswordHistoryEntries() []PasswordHistoryEntry {
+	h := r.PasswordHistory
+	if len(h) < 5 {
+		return nil
+	}
+
+	count, err := strconv.ParseUint(h[3:5], 16, 8)
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]PasswordHistoryEntry, 0, count)
+	pos := 5
+	for i := 0; i < int(count) && pos+12 <= len(h); i++ {
+		ts, err := strconv.ParseUint(h[pos:pos+8], 16, 32)
+		if err != nil {
+			break
+		}
+		size, err := strconv.ParseUint(h[pos+8:pos+12], 16, 16)
+		if err != nil || pos+12+int(size) > len(h) {
+			break
+		}
+		entries = append(entries, PasswordHistoryEntry{
+			Time:     time.Unix(int64(ts), 0),
+			Password: h[pos+12 : pos+12+int(size)],
+		})
+		pos += 12 + int(size)
+	}
+
+	return entries
+}
+
+// SetPasswordHistory Sets the previous passwords of the record (oldest first), keeping the most recent ones
+// up to the maximum number of entries of the record, 255 when the history settings are not set
+func (r *Record) SetPasswordHistory(entries []PasswordHistoryEntry) {
+	settings := "1ff"
+	max := maxPasswordHistory
+	if len(r.PasswordHistory) >= 5 {
+		if m, err := strconv.ParseUint(r.PasswordHistory[1:3], 16, 8); err == nil {
+			settings, max = r.PasswordHistory[:3], int(m)
+		}
+	} else if len(entries) == 0 {
+		r.PasswordHistory = ""
+		return
+	}
+	if len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%02x", settings, len(entries)))
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%08x%04x%s", uint32(e.Time.Unix()), len(e.Password), e.Password))
+	}
+	r.PasswordHistory = sb.String()
+}
+
+// PasswordModified Returns when the password was last changed, the zero time if unknown
+func (r Record) PasswordModified() time.Time {
+	if len(r.PasswordModTime) != 4 {
+		return time.Time{}
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint32([]byte(r.PasswordModTime))), 0)
+}
+
+// SetPasswordModified Sets when the password was last changed
+func (r *Record) SetPasswordModified(t time.Time) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(t.Unix()))
+	r.PasswordModTime = string(b[:])
+}
+
+// PasswordHistorySettings Returns if the password history is kept and the maximum number of entries
+func (r Record) PasswordHistorySettings() (bool, int) {
+	h := r.PasswordHistory
+	if len(h) < 5 {
+		return false, 0
+	}
+
+	max, err := strconv.ParseUint(h[1:3], 16, 8)
+	if err != nil {
+		return false, 0
+	}
+	return h[0] != '0', int(max)
+}
+
+// SetPasswordHistorySettings Sets if the password history is kept and its maximum number of entries
+func (r *Record) SetPasswordHistorySettings(enabled bool, max int) {
+	if max < 0 || max > maxPasswordHistory {
+		max = maxPasswordHistory
+	}
+	status := 0
+	if enabled {
+		status = 1
+	}
+
+	// the entries beyond the new maximum are dropped by SetPasswordHistory
+	entries := r.PasswordHistoryEntries()
+	r.PasswordHistory = fmt.Sprintf("%x%02x00", status, max)
+	r.SetPasswordHistory(entries)
+}
@@ -0,0 +1,90 @@
+package pwsafe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHistoryEntries(t *testing.T) {
+	rec := Record{PasswordHistory: "10302000000010003one5c0f4a000005three"}
+	entries := rec.PasswordHistoryEntries()
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "one", entries[0].Password)
+	assert.Equal(t, time.Unix(1, 0), entries[0].Time)
+	assert.Equal(t, "three", entries[1].Password)
+	assert.Equal(t, time.Unix(0x5c0f4a00, 0), entries[1].Time)
+
+	rec.PasswordHistory = "10302000000010009one"
+	assert.Equal(t, 0, len(rec.PasswordHistoryEntries()))
+
+	rec.PasswordHistory = ""
+	assert.Nil(t, rec.PasswordHistoryEntries())
+}
+
+func TestPasswordModified(t *testing.T) {
+	rec := Record{PasswordModTime: string([]byte{0x00, 0x4a, 0x0f, 0x5c})}
+	assert.Equal(t, time.Unix(0x5c0f4a00, 0), rec.PasswordModified())
+
+	rec.PasswordModTime = ""
+	assert.Equal(t, true, rec.PasswordModified().IsZero())
+}
+
+func TestSetPasswordHistory(t *testing.T) {
+	entries := []PasswordHistoryEntry{
+		{Time: time.Unix(1, 0), Password: "one"},
+		{Time: time.Unix(0x5c0f4a00, 0), Password: "three"},
+	}
+
+	var rec Record
+	rec.SetPasswordHistory(entries)
+	assert.Equal(t, "1ff02000000010003one5c0f4a000005three", rec.PasswordHistory)
+	assert.Equal(t, entries, rec.PasswordHistoryEntries())
+
+	rec.SetPasswordHistory(nil)
+	assert.Equal(t, "1ff00", rec.PasswordHistory)
+
+	rec.PasswordHistory = ""
+	rec.SetPasswordHistory(nil)
+	assert.Equal(t, "", rec.PasswordHistory)
+
+	// the maximum of the record is kept, the oldest entries are dropped
+	rec.PasswordHistory = "00100"
+	rec.SetPasswordHistory(entries)
+	assert.Equal(t, "001015c0f4a000005three", rec.PasswordHistory)
+	enabled, max := rec.PasswordHistorySettings()
+	assert.False(t, enabled)
+	assert.Equal(t, 1, max)
+}
+
+func TestSetPasswordHistorySettings(t *testing.T) {
+	var rec Record
+	rec.SetPasswordHistory([]PasswordHistoryEntry{
+		{Time: time.Unix(1, 0), Password: "one"},
+		{Time: time.Unix(2, 0), Password: "two"},
+		{Time: time.Unix(3, 0), Password: "three"},
+	})
+
+	rec.SetPasswordHistorySettings(true, 2)
+	enabled, max := rec.PasswordHistorySettings()
+	assert.True(t, enabled)
+	assert.Equal(t, 2, max)
+	assert.Equal(t, []PasswordHistoryEntry{{Time: time.Unix(2, 0), Password: "two"}, {Time: time.Unix(3, 0), Password: "three"}},
+		rec.PasswordHistoryEntries())
+
+	// a larger maximum keeps the entries
+	rec.SetPasswordHistorySettings(false, 10)
+	assert.Equal(t, "00a02000000020003two000000030005three", rec.PasswordHistory)
+
+	rec = Record{}
+	rec.SetPasswordHistorySettings(false, 0)
+	assert.Equal(t, "00000", rec.PasswordHistory)
+}
+
+func TestSetPasswordModified(t *testing.T) {
+	var rec Record
+	rec.SetPasswordModified(time.Unix(0x5c0f4a00, 0))
+	assert.Equal(t, string([]byte{0x00, 0x4a, 0x0f, 0x5c}), rec.PasswordModTime)
+	assert.Equal(t, time.Unix(0x5c0f4a00, 0), rec.PasswordModified())
+}
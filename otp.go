@@ -0,0 +1,160 @@
+package pwsafe
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default values used when the record TOTP fields are not set
+const (
+	DefaultTOTPLength   = 6
+	DefaultTOTPTimeStep = 30
+)
+
+// HOTP Returns the RFC 4226 one time password for the key and the counter
+func HOTP(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	bin := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	// 10^10 does not fit in 32 bits
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, bin%mod)
+}
+
+// TOTP Returns the RFC 6238 one time password for the key at the given time
+// along with the time remaining before the code changes
+func TOTP(key []byte, t time.Time, step int, start time.Time, digits int) (string, time.Duration) {
+	elapsed := t.Unix() - start.Unix()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	counter := uint64(elapsed) / uint64(step)
+	next := start.Unix() + int64(counter+1)*int64(step)
+
+	return HOTP(key, counter, digits), time.Unix(next, 0).Sub(t)
+}
+
+// HasOTP Returns true if the record holds a two factor key
+func (r Record) HasOTP() bool {
+	return len(r.TwoFactorKey) > 0
+}
+
+// OTP Returns the TOTP code of the record at the given time and the time remaining before it changes
+func (r Record) OTP(t time.Time) (string, time.Duration, error) {
+	if !r.HasOTP() {
+		return "", 0, errors.New("the record has no two factor key")
+	}
+
+	digits := int(r.TOTPLength)
+	if digits == 0 {
+		digits = DefaultTOTPLength
+	}
+	step := int(r.TOTPTimeStep)
+	if step == 0 {
+		step = DefaultTOTPTimeStep
+	}
+	start := r.TOTPStartTime
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+
+	code, remaining := TOTP(r.TwoFactorKey, t, step, start, digits)
+	return code, remaining, nil
+}
+
+// SetOTPAuthURI Sets the record two factor fields from an 'otpauth://totp/...' URI
+func (r *Record) SetOTPAuthURI(uri string) error {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "otpauth" {
+		return fmt.Errorf("invalid otpauth URI scheme '%s'", u.Scheme)
+	}
+	if u.Host != "totp" {
+		return fmt.Errorf("unsupported OTP type '%s', only totp is supported", u.Host)
+	}
+
+	q := u.Query()
+	if algo := q.Get("algorithm"); algo != "" && !strings.EqualFold(algo, "SHA1") {
+		return fmt.Errorf("unsupported OTP algorithm '%s', only SHA1 is supported", algo)
+	}
+
+	key, err := DecodeOTPSecret(q.Get("secret"))
+	if err != nil {
+		return err
+	}
+
+	var digits, period uint64
+	if v := q.Get("digits"); v != "" {
+		if digits, err = strconv.ParseUint(v, 10, 8); err != nil || digits < 6 || digits > 10 {
+			return fmt.Errorf("invalid OTP digits '%s'", v)
+		}
+	}
+	if v := q.Get("period"); v != "" {
+		if period, err = strconv.ParseUint(v, 10, 8); err != nil || period == 0 {
+			return fmt.Errorf("invalid OTP period '%s'", v)
+		}
+	}
+
+	r.TwoFactorKey = key
+	r.TOTPLength = byte(digits)
+	r.TOTPTimeStep = byte(period)
+	r.TOTPStartTime = time.Time{}
+	return nil
+}
+
+// OTPAuthURI Returns the 'otpauth://totp/...' URI of the record two factor fields, the empty string if unset
+func (r Record) OTPAuthURI() string {
+	if !r.HasOTP() {
+		return ""
+	}
+
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(r.TwoFactorKey))
+	if r.TOTPLength != 0 {
+		q.Set("digits", strconv.Itoa(int(r.TOTPLength)))
+	}
+	if r.TOTPTimeStep != 0 {
+		q.Set("period", strconv.Itoa(int(r.TOTPTimeStep)))
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + r.Title, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// DecodeOTPSecret Decodes a base32 OTP secret as found in otpauth URIs (padding and spaces are optional)
+func DecodeOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.Replace(strings.TrimSpace(secret), " ", "", -1))
+	secret = strings.TrimRight(secret, "=")
+	if secret == "" {
+		return nil, errors.New("missing OTP secret")
+	}
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTP secret - %s", err.Error())
+	}
+	return key, nil
+}
@@ -0,0 +1,99 @@
+package pwsafe
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 4226 Appendix D and RFC 6238 Appendix B (SHA1)
+var rfcSecret = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range expected {
+		assert.Equal(t, code, HOTP(rfcSecret, uint64(counter), 6))
+	}
+}
+
+func TestHOTP10Digits(t *testing.T) {
+	// the truncated values of RFC 4226 Appendix D, they have at most 10 digits
+	expected := []string{"1284755224", "1094287082", "0137359152", "1726969429", "1640338314",
+		"0868254676", "1918287922", "0082162583", "0673399871", "0645520489"}
+	for counter, code := range expected {
+		assert.Equal(t, code, HOTP(rfcSecret, uint64(counter), 10))
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	var testData = []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+	}
+
+	for _, test := range testData {
+		code, remaining := TOTP(rfcSecret, time.Unix(test.unix, 0), 30, time.Unix(0, 0), 8)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, time.Duration(30-test.unix%30)*time.Second, remaining)
+	}
+}
+
+func TestRecordOTP(t *testing.T) {
+	var record Record
+	_, _, err := record.OTP(time.Now())
+	assert.NotNil(t, err)
+
+	err = record.SetOTPAuthURI("otpauth://totp/ACME:john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME&digits=8")
+	assert.Nil(t, err)
+	assert.Equal(t, rfcSecret, record.TwoFactorKey)
+	assert.Equal(t, byte(8), record.TOTPLength)
+
+	code, _, err := record.OTP(time.Unix(59, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, "94287082", code)
+
+	assert.NotNil(t, record.SetOTPAuthURI("otpauth://hotp/ACME:john?secret=GEZDGNBV&counter=1"))
+	assert.NotNil(t, record.SetOTPAuthURI("otpauth://totp/ACME:john?secret=not-base32!"))
+}
+
+// TestOTPFieldsRoundTrip verify the two factor fields survive encryption and decryption
+func TestOTPFieldsRoundTrip(t *testing.T) {
+	db := NewV3("", "password")
+	record := Record{Title: "otp entry", Password: "password", TwoFactorKey: rfcSecret, TOTPLength: 8, TOTPTimeStep: 60}
+	db.SetRecord(record)
+
+	var buf bytes.Buffer
+	_, err := db.Encrypt(&buf)
+	assert.Nil(t, err)
+
+	var readDB V3
+	_, err = readDB.Decrypt(&buf, "password")
+	assert.Nil(t, err)
+
+	read, exists := readDB.GetRecord("otp entry")
+	assert.Equal(t, true, exists)
+	assert.Equal(t, rfcSecret, read.TwoFactorKey)
+	assert.Equal(t, byte(8), read.TOTPLength)
+	assert.Equal(t, byte(60), read.TOTPTimeStep)
+}
+
+func TestOTPAuthURI(t *testing.T) {
+	record := Record{Title: "my site", TwoFactorKey: rfcSecret, TOTPLength: 8}
+	uri := record.OTPAuthURI()
+	assert.Equal(t, "otpauth://totp/my%20site?digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri)
+
+	var parsed Record
+	assert.Nil(t, parsed.SetOTPAuthURI(uri))
+	assert.Equal(t, rfcSecret, parsed.TwoFactorKey)
+	assert.Equal(t, byte(8), parsed.TOTPLength)
+
+	assert.Equal(t, "", Record{}.OTPAuthURI())
+}
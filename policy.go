@@ -0,0 +1,213 @@
+package pwsafe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the flags of the password policy fields
+const (
+	policyUseLowercase      = 0x8000
+	policyUseUppercase      = 0x4000
+	policyUseDigits         = 0x2000
+	policyUseSymbols        = 0x1000
+	policyUseHexDigits      = 0x0800
+	policyUseEasyVision     = 0x0400
+	policyMakePronounceable = 0x0200
+)
+
+// policyLength is the length of the encoded policy "ffffnnnllluuudddsss"
+const policyLength = 19
+
+// PasswordPolicy is the password generation policy of a record (field 0x10)
+// or one of the named policies of the db (header field 0x10)
+type PasswordPolicy struct {
+	Name               string // only for the named policies
+	UseLowercase       bool
+	UseUppercase       bool
+	UseDigits          bool
+	UseSymbols         bool
+	UseHexDigits       bool
+	UseEasyVision      bool
+	MakePronounceable  bool
+	Length             int
+	LowercaseMinLength int
+	UppercaseMinLength int
+	DigitMinLength     int
+	SymbolMinLength    int
+	Symbols            string // only for the named policies, the default symbols if empty
+}
+
+// ParsePasswordPolicy Parses the record policy field "ffffnnnllluuudddsss",
+// the flags followed by the length and the minimum lowercase, uppercase, digits and symbols as hex
+func ParsePasswordPolicy(s string) (PasswordPolicy, error) {
+	var p PasswordPolicy
+	if len(s) != policyLength {
+		return p, fmt.Errorf("invalid password policy '%s'", s)
+	}
+
+	var values [6]uint64
+	for i, pos := range []int{0, 4, 7, 10, 13, 16} {
+		end := pos + 3
+		if i == 0 {
+			end = pos + 4
+		}
+		v, err := strconv.ParseUint(s[pos:end], 16, 16)
+		if err != nil {
+			return p, fmt.Errorf("invalid password policy '%s'", s)
+		}
+		values[i] = v
+	}
+
+	flags := values[0]
+	p.UseLowercase = flags&policyUseLowercase != 0
+	p.UseUppercase = flags&policyUseUppercase != 0
+	p.UseDigits = flags&policyUseDigits != 0
+	p.UseSymbols = flags&policyUseSymbols != 0
+	p.UseHexDigits = flags&policyUseHexDigits != 0
+	p.UseEasyVision = flags&policyUseEasyVision != 0
+	p.MakePronounceable = flags&policyMakePronounceable != 0
+	p.Length = int(values[1])
+	p.LowercaseMinLength = int(values[2])
+	p.UppercaseMinLength = int(values[3])
+	p.DigitMinLength = int(values[4])
+	p.SymbolMinLength = int(values[5])
+	return p, nil
+}
+
+// String Returns the policy encoded as the record policy field
+func (p PasswordPolicy) String() string {
+	var flags uint16
+	for flag, set := range map[uint16]bool{
+		policyUseLowercase:      p.UseLowercase,
+		policyUseUppercase:      p.UseUppercase,
+		policyUseDigits:         p.UseDigits,
+		policyUseSymbols:        p.UseSymbols,
+		policyUseHexDigits:      p.UseHexDigits,
+		policyUseEasyVision:     p.UseEasyVision,
+		policyMakePronounceable: p.MakePronounceable,
+	} {
+		if set {
+			flags |= flag
+		}
+	}
+
+	return fmt.Sprintf("%04x%03x%03x%03x%03x%03x", flags, p.Length,
+		p.LowercaseMinLength, p.UppercaseMinLength, p.DigitMinLength, p.SymbolMinLength)
+}
+
+// ParseNamedPasswordPolicies Parses the header named policies field, "NN" (the number of policies as hex)
+// followed by "LL<name>" + the record policy + "LL<symbols>" for each policy
+func ParseNamedPasswordPolicies(s string) ([]PasswordPolicy, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	next := func(n int) (string, error) {
+		if len(s) < n {
+			return "", fmt.Errorf("invalid named password policies field")
+		}
+		res := s[:n]
+		s = s[n:]
+		return res, nil
+	}
+	nextLen := func() (int, error) {
+		v, err := next(2)
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseUint(v, 16, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid named password policies field")
+		}
+		return int(n), nil
+	}
+
+	count, err := nextLen()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]PasswordPolicy, 0, count)
+	for i := 0; i < count; i++ {
+		size, err := nextLen()
+		if err != nil {
+			return nil, err
+		}
+		name, err := next(size)
+		if err != nil {
+			return nil, err
+		}
+		field, err := next(policyLength)
+		if err != nil {
+			return nil, err
+		}
+		p, err := ParsePasswordPolicy(field)
+		if err != nil {
+			return nil, err
+		}
+		if size, err = nextLen(); err != nil {
+			return nil, err
+		}
+		if p.Symbols, err = next(size); err != nil {
+			return nil, err
+		}
+		p.Name = name
+		res = append(res, p)
+	}
+
+	return res, nil
+}
+
+// FormatNamedPasswordPolicies Returns the policies encoded as the header named policies field
+func FormatNamedPasswordPolicies(policies []PasswordPolicy) (string, error) {
+	if len(policies) == 0 {
+		return "", nil
+	}
+	if len(policies) > 0xff {
+		return "", fmt.Errorf("too many named password policies: %d", len(policies))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%02x", len(policies)))
+	for _, p := range policies {
+		if p.Name == "" || len(p.Name) > 0xff || len(p.Symbols) > 0xff {
+			return "", fmt.Errorf("invalid named password policy '%s'", p.Name)
+		}
+		sb.WriteString(fmt.Sprintf("%02x%s%s%02x%s", len(p.Name), p.Name, p.String(), len(p.Symbols), p.Symbols))
+	}
+	return sb.String(), nil
+}
+
+// NamedPasswordPolicies Returns the named password policies of the db
+func (db V3) NamedPasswordPolicies() ([]PasswordPolicy, error) {
+	return ParseNamedPasswordPolicies(db.PasswordPolicy)
+}
+
+// SetNamedPasswordPolicy Adds or replaces the named password policy with the same name
+func (db *V3) SetNamedPasswordPolicy(policy PasswordPolicy) error {
+	policies, err := db.NamedPasswordPolicies()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, p := range policies {
+		if p.Name == policy.Name {
+			policies[i], found = policy, true
+		}
+	}
+	if !found {
+		policies = append(policies, policy)
+	}
+
+	field, err := FormatNamedPasswordPolicies(policies)
+	if err != nil {
+		return err
+	}
+	db.PasswordPolicy = field
+	db.LastMod = time.Now()
+	return nil
+}
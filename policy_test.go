@@ -0,0 +1,58 @@
+package pwsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	p, err := ParsePasswordPolicy("b00001000200000003")
+	assert.NotNil(t, err)
+
+	p, err = ParsePasswordPolicy("b0000100020000000003")
+	assert.NotNil(t, err)
+
+	p, err = ParsePasswordPolicy("b000010002000000003")
+	assert.Nil(t, err)
+	assert.Equal(t, true, p.UseLowercase)
+	assert.Equal(t, false, p.UseUppercase)
+	assert.Equal(t, true, p.UseDigits)
+	assert.Equal(t, true, p.UseSymbols)
+	assert.Equal(t, 16, p.Length)
+	assert.Equal(t, 2, p.LowercaseMinLength)
+	assert.Equal(t, 3, p.SymbolMinLength)
+	assert.Equal(t, "b000010002000000003", p.String())
+}
+
+func TestNamedPasswordPolicies(t *testing.T) {
+	policies := []PasswordPolicy{
+		{Name: "pin", UseDigits: true, Length: 6, DigitMinLength: 6},
+		{Name: "web", UseLowercase: true, UseSymbols: true, Length: 20, Symbols: "#$%"},
+	}
+
+	field, err := FormatNamedPasswordPolicies(policies)
+	assert.Nil(t, err)
+	assert.Equal(t, "0203pin20000060000000060000003web900001400000000000003#$%", field)
+
+	parsed, err := ParseNamedPasswordPolicies(field)
+	assert.Nil(t, err)
+	assert.Equal(t, policies, parsed)
+
+	_, err = ParseNamedPasswordPolicies(field[:len(field)-1])
+	assert.NotNil(t, err)
+}
+
+func TestSetNamedPasswordPolicy(t *testing.T) {
+	db := NewV3("", "password")
+	assert.Nil(t, db.SetNamedPasswordPolicy(PasswordPolicy{Name: "pin", UseDigits: true, Length: 4}))
+	assert.Nil(t, db.SetNamedPasswordPolicy(PasswordPolicy{Name: "web", UseLowercase: true, Length: 20}))
+	assert.Nil(t, db.SetNamedPasswordPolicy(PasswordPolicy{Name: "pin", UseDigits: true, Length: 6}))
+	assert.NotNil(t, db.SetNamedPasswordPolicy(PasswordPolicy{}))
+
+	policies, err := db.NamedPasswordPolicies()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(policies))
+	assert.Equal(t, 6, policies[0].Length)
+	assert.Equal(t, "web", policies[1].Name)
+}
@@ -0,0 +1,310 @@
+package pwsafe
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PasswordStrength is the estimated strength of a password
+type PasswordStrength struct {
+	Entropy float64 // log2 of the estimated number of guesses
+	Score   int     // from 0 (too guessable) to 4 (very unguessable)
+}
+
+// the scores thresholds, as log10 of the number of guesses
+var strengthThresholds = []float64{3, 6, 8, 10}
+
+// the guesses of each random character and the minimum guesses of a pattern
+const (
+	bruteforceCardinality = 10
+	minGuessesPattern     = 50
+)
+
+// maxStrengthLength is the number of characters analyzed, as zxcvbn the longer passwords
+// are truncated since the estimate is quadratic in the length (and they are strong anyway)
+const maxStrengthLength = 100
+
+// the rows of a qwerty keyboard and a keypad, for the spatial sequences
+var keyboardRows = []string{
+	"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./", "789456123",
+}
+
+// leetSubstitutions are the common character substitutions
+var leetSubstitutions = map[rune][]rune{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '3': {'e'}, '6': {'g'}, '9': {'g'},
+	'1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'}, '0': {'o'}, '$': {'s'}, '5': {'s'},
+	'7': {'t'}, '+': {'t'}, '2': {'z'},
+}
+
+// commonPasswords are the most used passwords and words, the most common first
+var commonPasswords = strings.Fields(`
+	123456 password 12345678 qwerty 123456789 12345 1234 111111 1234567 dragon
+	123123 baseball abc123 football monkey letmein shadow master 666666 qwertyuiop
+	123321 mustang 1234567890 michael 654321 superman 1qaz2wsx 7777777 121212 000000
+	qazwsx 123qwe killer trustno1 jordan jennifer zxcvbnm asdfgh hunter buster
+	soccer harley batman andrew tigger sunshine iloveyou 2000 charlie robert thomas
+	hockey ranger daniel starwars klaster 112233 george computer michelle jessica
+	pepper 1111 zxcvbn 555555 11111111 131313 freedom 777777 pass maggie
+	159753 aaaaaa ginger princess joshua cheese amanda summer love ashley
+	nicole chelsea biteme matthew access yankees 987654321 dallas austin thunder
+	taylor matrix mobilemail mom monitor monitoring montana moon moscow
+	welcome admin administrator login secret root changeme default guest test
+	hello world love god money office winter spring autumn company service
+	system user private internet server network access database manager
+	apple orange banana family friend forever flower dream angel heaven
+	qwerty123 password1 welcome1 admin123 letmein1 passw0rd p@ssw0rd iloveyou1
+`)
+
+// commonPasswordRanks maps each common password to its rank
+var commonPasswordRanks = func() map[string]int {
+	res := make(map[string]int, len(commonPasswords))
+	for i, w := range commonPasswords {
+		if _, ok := res[w]; !ok {
+			res[w] = i + 1
+		}
+	}
+	return res
+}()
+
+// EstimatePasswordStrength Estimates how many guesses an attacker needs to find the password,
+// in the manner of zxcvbn: the password is split in the sequence of common words,
+// repeats, sequences, keyboard patterns, years and random characters that is the easiest to guess.
+// The user inputs (i.e. the username and the title) are treated as the most common words.
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) > maxStrengthLength {
+		runes = runes[:maxStrengthLength]
+	}
+	n := len(runes)
+	if n == 0 {
+		return PasswordStrength{}
+	}
+
+	ranks := make(map[string]int)
+	for i, in := range userInputs {
+		if in = strings.ToLower(strings.TrimSpace(in)); in != "" {
+			ranks[in] = i + 1
+		}
+	}
+
+	// best[j] is the lowest log2 guesses of the first j characters, count[j] the number of matches
+	best := make([]float64, n+1)
+	count := make([]int, n+1)
+	for j := 1; j <= n; j++ {
+		best[j] = math.Inf(1)
+		for i := 0; i < j; i++ {
+			bits := best[i] + math.Log2(matchGuesses(runes[i:j], ranks))
+			if bits < best[j] || (bits == best[j] && count[i]+1 < count[j]) {
+				best[j], count[j] = bits, count[i]+1
+			}
+		}
+	}
+
+	// the attacker does not know the order of the patterns
+	entropy := best[n]
+	for k := 2; k <= count[n]; k++ {
+		entropy += math.Log2(float64(k))
+	}
+
+	res := PasswordStrength{Entropy: entropy}
+	log10 := entropy * math.Log10(2)
+	for _, t := range strengthThresholds {
+		if log10 >= t {
+			res.Score++
+		}
+	}
+	return res
+}
+
+// matchGuesses returns the guesses of the characters as a single pattern or as random characters
+func matchGuesses(s []rune, ranks map[string]int) float64 {
+	res := math.Pow(bruteforceCardinality, float64(len(s)))
+	if len(s) == 1 {
+		return res
+	}
+
+	for _, g := range []float64{
+		dictionaryGuesses(s, ranks),
+		repeatGuesses(s),
+		sequenceGuesses(s),
+		keyboardGuesses(s),
+		yearGuesses(s),
+	} {
+		if g > 0 && g < res {
+			res = math.Max(g, minGuessesPattern)
+		}
+	}
+	return res
+}
+
+// dictionaryGuesses returns the rank of the word times its upper case and leet variations,
+// doubled when the word is reversed
+func dictionaryGuesses(s []rune, ranks map[string]int) float64 {
+	rank := func(w string) int {
+		if r, ok := ranks[w]; ok {
+			return r
+		}
+		return commonPasswordRanks[w]
+	}
+
+	lower := []rune(strings.ToLower(string(s)))
+	res := 0.0
+	for _, w := range unleet(lower) {
+		subs := 0
+		for i := range w {
+			if w[i] != lower[i] {
+				subs++
+			}
+		}
+		for reversed, word := range []string{string(w), reverse(w)} {
+			r := rank(word)
+			if r == 0 {
+				continue
+			}
+			g := float64(r) * uppercaseVariations(s) * math.Pow(2, float64(subs))
+			if reversed == 1 {
+				g *= 2
+			}
+			if res == 0 || g < res {
+				res = g
+			}
+		}
+	}
+	return res
+}
+
+// unleet returns the word with and without the leet substitutions
+func unleet(s []rune) [][]rune {
+	res := [][]rune{s}
+	for _, alt := range []int{0, 1} {
+		w := make([]rune, len(s))
+		changed := false
+		for i, c := range s {
+			w[i] = c
+			if subs, ok := leetSubstitutions[c]; ok {
+				w[i] = subs[alt%len(subs)]
+				changed = true
+			}
+		}
+		if changed {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// uppercaseVariations returns how many ways the word could be capitalized
+func uppercaseVariations(s []rune) float64 {
+	upper, lower := 0, 0
+	for _, c := range s {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0 || (upper == 1 && unicode.IsUpper(s[0])):
+		// all upper case or capitalized
+		return 2
+	case upper < lower:
+		return math.Pow(2, float64(upper))
+	default:
+		return math.Pow(2, float64(lower))
+	}
+}
+
+// repeatGuesses returns the guesses of the same character repeated
+func repeatGuesses(s []rune) float64 {
+	for _, c := range s[1:] {
+		if c != s[0] {
+			return 0
+		}
+	}
+	return float64(cardinality(s[0]) * len(s))
+}
+
+// sequenceGuesses returns the guesses of the characters in alphabetical or numerical order
+func sequenceGuesses(s []rune) float64 {
+	if len(s) < 3 {
+		return 0
+	}
+
+	delta := s[1] - s[0]
+	if delta != 1 && delta != -1 {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i]-s[i-1] != delta {
+			return 0
+		}
+	}
+
+	base := float64(cardinality(s[0]))
+	switch s[0] {
+	case 'a', 'A', 'z', 'Z', '0', '1', '9':
+		// the obvious starting points
+		base = 4
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return base * float64(len(s))
+}
+
+// keyboardGuesses returns the guesses of the characters adjacent on a keyboard row
+func keyboardGuesses(s []rune) float64 {
+	if len(s) < 3 {
+		return 0
+	}
+
+	w := strings.ToLower(string(s))
+	for _, row := range keyboardRows {
+		if strings.Contains(row, w) {
+			return float64(len(row)) * float64(len(s))
+		}
+		if strings.Contains(row, reverse([]rune(w))) {
+			return 2 * float64(len(row)) * float64(len(s))
+		}
+	}
+	return 0
+}
+
+// yearGuesses returns the guesses of a recent year
+func yearGuesses(s []rune) float64 {
+	if len(s) != 4 {
+		return 0
+	}
+	w := string(s)
+	if (strings.HasPrefix(w, "19") || strings.HasPrefix(w, "20")) && strings.Trim(w, "0123456789") == "" {
+		return 120
+	}
+	return 0
+}
+
+// cardinality returns the size of the character class
+func cardinality(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return 10
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return 26
+	case c < 0x80:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func reverse(s []rune) string {
+	res := make([]rune, len(s))
+	for i, c := range s {
+		res[len(s)-1-i] = c
+	}
+	return string(res)
+}
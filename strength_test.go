@@ -0,0 +1,51 @@
+package pwsafe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	var testData = []struct {
+		password string
+		inputs   []string
+		score    int
+	}{
+		{password: "", score: 0},
+		{password: "password", score: 0},
+		{password: "P@ssw0rd", score: 0},
+		{password: "drowssap", score: 0},
+		{password: "qwertyuiop", score: 0},
+		{password: "aaaaaaaaaaaa", score: 0},
+		{password: "abcdefgh", score: 0},
+		{password: "dragon1987", score: 1},
+		{password: "jsmith2019", inputs: []string{"jsmith"}, score: 1},
+		{password: "correct-horse-battery", score: 4},
+		{password: "Xk9#mQ2$vL7!", score: 4},
+		{password: "7hG2kP9q", score: 3},
+	}
+
+	for _, tt := range testData {
+		got := EstimatePasswordStrength(tt.password, tt.inputs...)
+		assert.Equal(t, tt.score, got.Score, tt.password)
+	}
+}
+
+func TestEstimatePasswordStrengthLongPassword(t *testing.T) {
+	// i.e. an SSH private key stored as password
+	long := strings.Repeat("MIIEowIBAAKCAQEAx9Lq3+", 150)
+
+	start := time.Now()
+	got := EstimatePasswordStrength(long)
+	assert.Equal(t, 4, got.Score)
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestEstimatePasswordStrengthUserInputs(t *testing.T) {
+	without := EstimatePasswordStrength("jsmith")
+	with := EstimatePasswordStrength("jsmith", "JSmith")
+	assert.True(t, with.Entropy < without.Entropy)
+}
@@ -0,0 +1,478 @@
+// The Password Safe desktop client XML format
+// The schema - https://github.com/pwsafe/pwsafe/blob/master/xml/pwsafe.xsd
+
+package pwsafe
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// xmlTimeFormat is the layout of the XML times, in local time as the desktop client writes them
+const xmlTimeFormat = "2006-01-02T15:04:05"
+
+// xmlText is written as a CDATA section, so that notes and passwords are kept verbatim
+type xmlText struct {
+	Value string `xml:",cdata"`
+}
+
+type xmlDB struct {
+	XMLName            xml.Name `xml:"passwordsafe"`
+	Delimiter          string   `xml:"delimiter,attr"`
+	XSI                string   `xml:"xmlns:xsi,attr,omitempty"`
+	Database           string   `xml:"Database,attr,omitempty"`
+	ExportTimeStamp    string   `xml:"ExportTimeStamp,attr,omitempty"`
+	FromDatabaseFormat string   `xml:"FromDatabaseFormat,attr,omitempty"`
+	WhatSaved          string   `xml:"WhatSaved,attr,omitempty"`
+	WhenLastSaved      string   `xml:"WhenLastSaved,attr,omitempty"`
+	WhoSaved           string   `xml:"WhoSaved,attr,omitempty"`
+	Schema             string   `xml:"xsi:noNamespaceSchemaLocation,attr,omitempty"`
+
+	NumberHashIterations uint32          `xml:"NumberHashIterations,omitempty"`
+	NamedPolicies        *xmlPolicies    `xml:"NamedPasswordPolicies"`
+	EmptyGroups          *xmlEmptyGroups `xml:"EmptyGroups"`
+
+	Entries []xmlEntry `xml:"entry"`
+}
+
+// the wrappers are pointers so that they are omitted when there is nothing to write
+type xmlPolicies struct {
+	Policies []xmlPolicy `xml:"Policy"`
+}
+
+type xmlEmptyGroups struct {
+	Groups []xmlText `xml:"EGName"`
+}
+
+type xmlHistoryEntries struct {
+	Entries []xmlHistoryEntry `xml:"history_entry"`
+}
+
+type xmlPolicy struct {
+	Name               *xmlText `xml:"PWName"`
+	Length             int      `xml:"PWLength"`
+	UseLowercase       int      `xml:"PWUseLowercase"`
+	UseUppercase       int      `xml:"PWUseUppercase"`
+	UseDigits          int      `xml:"PWUseDigits"`
+	UseSymbols         int      `xml:"PWUseSymbols"`
+	UseHexDigits       int      `xml:"PWUseHexDigits"`
+	UseEasyVision      int      `xml:"PWUseEasyVision"`
+	MakePronounceable  int      `xml:"PWMakePronounceable"`
+	LowercaseMinLength int      `xml:"PWLowercaseMinLength"`
+	UppercaseMinLength int      `xml:"PWUppercaseMinLength"`
+	DigitMinLength     int      `xml:"PWDigitMinLength"`
+	SymbolMinLength    int      `xml:"PWSymbolMinLength"`
+	Symbols            *xmlText `xml:"symbols"`
+}
+
+type xmlHistoryEntry struct {
+	Num         int      `xml:"num,attr"`
+	Changed     string   `xml:"changedx"`
+	OldPassword *xmlText `xml:"oldpassword"`
+}
+
+type xmlHistory struct {
+	Status  int                `xml:"status"`
+	Max     int                `xml:"max"`
+	Num     int                `xml:"num"`
+	Entries *xmlHistoryEntries `xml:"history_entries"`
+}
+
+type xmlEntry struct {
+	ID                 int         `xml:"id,attr,omitempty"`
+	Group              *xmlText    `xml:"group"`
+	Title              *xmlText    `xml:"title"`
+	Username           *xmlText    `xml:"username"`
+	Password           *xmlText    `xml:"password"`
+	TwoFactorKey       string      `xml:"twofactorkey,omitempty"`
+	TOTPStartTime      string      `xml:"totpstarttime,omitempty"`
+	TOTPTimeStep       int         `xml:"totptimestep,omitempty"`
+	TOTPLength         int         `xml:"totplength,omitempty"`
+	URL                *xmlText    `xml:"url"`
+	Autotype           *xmlText    `xml:"autotype"`
+	Notes              *xmlText    `xml:"notes"`
+	UUID               string      `xml:"uuid,omitempty"`
+	CreateTime         string      `xml:"ctimex,omitempty"`
+	AccessTime         string      `xml:"atimex,omitempty"`
+	PasswordExpiry     string      `xml:"xtimex,omitempty"`
+	PasswordModTime    string      `xml:"pmtimex,omitempty"`
+	ModTime            string      `xml:"rmtimex,omitempty"`
+	ExpiryInterval     int         `xml:"xtime_interval,omitempty"`
+	History            *xmlHistory `xml:"pwhistory"`
+	RunCommand         *xmlText    `xml:"runcommand"`
+	DoubleClickAction  string      `xml:"dca,omitempty"`
+	ShiftDoubleClick   string      `xml:"shiftdca,omitempty"`
+	Email              *xmlText    `xml:"email"`
+	Protected          int         `xml:"protected,omitempty"`
+	PasswordPolicy     *xmlPolicy  `xml:"PasswordPolicy"`
+	PasswordPolicyName *xmlText    `xml:"PasswordPolicyName"`
+}
+
+// WriteXML Writes the db in the Password Safe desktop client XML format.
+// Only the elements of the schema are written, so the header fields without a counterpart
+// (i.e. the name, the description, the uuid and the preferences of the db) are not exported.
+func WriteXML(db DB, w io.Writer) error {
+	//Only type pwsafe.V3 is currently supported
+	v3db := db.(*V3)
+
+	doc := xmlDB{
+		Delimiter:            "\u00ad",
+		XSI:                  "http://www.w3.org/2001/XMLSchema-instance",
+		Database:             v3db.LastSavePath,
+		ExportTimeStamp:      xmlTime(time.Now()),
+		FromDatabaseFormat:   fmt.Sprintf("%d.%02d", v3db.Version[1], v3db.Version[0]),
+		WhatSaved:            string(v3db.LastSaveBy),
+		WhenLastSaved:        xmlTime(v3db.LastSave),
+		Schema:               "pwsafe.xsd",
+		NumberHashIterations: v3db.Iter,
+	}
+	if len(v3db.LastSaveUser) > 0 {
+		doc.WhoSaved = string(v3db.LastSaveUser)
+		if len(v3db.LastSaveHost) > 0 {
+			doc.WhoSaved += " on " + string(v3db.LastSaveHost)
+		}
+	}
+
+	policies, err := ParseNamedPasswordPolicies(v3db.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		doc.NamedPolicies = &xmlPolicies{}
+		for _, p := range policies {
+			doc.NamedPolicies.Policies = append(doc.NamedPolicies.Policies, newXMLPolicy(p, true))
+		}
+	}
+	if len(v3db.EmptyGroups) > 0 {
+		doc.EmptyGroups = &xmlEmptyGroups{}
+		for _, g := range v3db.EmptyGroups {
+			doc.EmptyGroups.Groups = append(doc.EmptyGroups.Groups, xmlText{Value: g})
+		}
+	}
+
+	for i, title := range v3db.List() {
+		doc.Entries = append(doc.Entries, newXMLEntry(i+1, v3db.Records[title]))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// ReadXML Reads a db written in the Password Safe XML format.
+// The header fields are returned in the db, while the records are returned apart
+// since the desktop client allows the same title in different groups.
+func ReadXML(r io.Reader) (*V3, []Record, error) {
+	var doc xmlDB
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid Password Safe XML document - %s", err.Error())
+	}
+
+	db := &V3{
+		LastSaveBy:   []byte(doc.WhatSaved),
+		LastSavePath: doc.Database,
+		LastSave:     parseXMLTime(doc.WhenLastSaved),
+		Iter:         doc.NumberHashIterations,
+		Records:      make(map[string]Record),
+		Version:      [2]byte{0x10, 0x03},
+	}
+	// WhoSaved is 'user on host'
+	if who := strings.SplitN(doc.WhoSaved, " on ", 2); who[0] != "" {
+		db.LastSaveUser = []byte(who[0])
+		if len(who) == 2 {
+			db.LastSaveHost = []byte(who[1])
+		}
+	}
+
+	var policies []PasswordPolicy
+	if doc.NamedPolicies != nil {
+		for _, p := range doc.NamedPolicies.Policies {
+			policies = append(policies, p.policy())
+		}
+	}
+	field, err := FormatNamedPasswordPolicies(policies)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.PasswordPolicy = field
+
+	if doc.EmptyGroups != nil {
+		for _, g := range doc.EmptyGroups.Groups {
+			if g.Value != "" {
+				db.EmptyGroups = append(db.EmptyGroups, g.Value)
+			}
+		}
+	}
+	sort.Strings(db.EmptyGroups)
+
+	records := make([]Record, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		rec, err := e.record()
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, rec)
+	}
+
+	return db, records, nil
+}
+
+func newXMLEntry(id int, r Record) xmlEntry {
+	e := xmlEntry{
+		ID:                 id,
+		Group:              newXMLText(r.Group),
+		Title:              newXMLText(r.Title),
+		Username:           newXMLText(r.Username),
+		Password:           newXMLText(r.Password),
+		URL:                newXMLText(r.URL),
+		Autotype:           newXMLText(r.Autotype),
+		Notes:              newXMLText(r.Notes),
+		UUID:               hex.EncodeToString(r.UUID[:]),
+		CreateTime:         xmlTime(r.CreateTime),
+		AccessTime:         xmlTime(r.AccessTime),
+		PasswordExpiry:     xmlTime(r.PasswordExpiry),
+		PasswordModTime:    xmlTime(r.PasswordModified()),
+		ModTime:            xmlTime(r.ModTime),
+		ExpiryInterval:     r.PasswordExpiryDays(),
+		RunCommand:         newXMLText(r.RunCommand),
+		Email:              newXMLText(r.Email),
+		Protected:          int(r.ProtectedEntry),
+		PasswordPolicyName: newXMLText(r.PasswordPolicyName),
+	}
+
+	if r.HasOTP() {
+		e.TwoFactorKey = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(r.TwoFactorKey)
+		e.TOTPStartTime = xmlTime(r.TOTPStartTime)
+		e.TOTPTimeStep = int(r.TOTPTimeStep)
+		e.TOTPLength = int(r.TOTPLength)
+	}
+	if r.DoubleClickAction != [2]byte{} {
+		e.DoubleClickAction = strconv.Itoa(int(binary.LittleEndian.Uint16(r.DoubleClickAction[:])))
+	}
+	if r.ShiftDoubleClickAction != [2]byte{} {
+		e.ShiftDoubleClick = strconv.Itoa(int(binary.LittleEndian.Uint16(r.ShiftDoubleClickAction[:])))
+	}
+
+	if r.PasswordHistory != "" {
+		enabled, max := r.PasswordHistorySettings()
+		h := &xmlHistory{Max: max}
+		if enabled {
+			h.Status = 1
+		}
+		for i, old := range r.PasswordHistoryEntries() {
+			if h.Entries == nil {
+				h.Entries = &xmlHistoryEntries{}
+			}
+			h.Entries.Entries = append(h.Entries.Entries, xmlHistoryEntry{
+				Num:         i + 1,
+				Changed:     xmlTime(old.Time),
+				OldPassword: &xmlText{Value: old.Password},
+			})
+			h.Num = i + 1
+		}
+		e.History = h
+	}
+
+	if p, err := ParsePasswordPolicy(r.PasswordPolicy); err == nil {
+		xp := newXMLPolicy(p, false)
+		e.PasswordPolicy = &xp
+	}
+
+	return e
+}
+
+func (e xmlEntry) record() (Record, error) {
+	r := Record{
+		Group:              e.Group.text(),
+		Title:              e.Title.text(),
+		Username:           e.Username.text(),
+		Password:           e.Password.text(),
+		URL:                e.URL.text(),
+		Autotype:           e.Autotype.text(),
+		Notes:              e.Notes.text(),
+		CreateTime:         parseXMLTime(e.CreateTime),
+		AccessTime:         parseXMLTime(e.AccessTime),
+		PasswordExpiry:     parseXMLTime(e.PasswordExpiry),
+		ModTime:            parseXMLTime(e.ModTime),
+		RunCommand:         e.RunCommand.text(),
+		Email:              e.Email.text(),
+		ProtectedEntry:     byte(e.Protected),
+		PasswordPolicyName: e.PasswordPolicyName.text(),
+	}
+	if err := decodeXMLUUID(e.UUID, &r.UUID); err != nil {
+		return r, err
+	}
+	if t := parseXMLTime(e.PasswordModTime); !t.IsZero() {
+		r.SetPasswordModified(t)
+	}
+	if e.ExpiryInterval > 0 {
+		binary.LittleEndian.PutUint32(r.PasswordExpiryInterval[:], uint32(e.ExpiryInterval))
+	}
+	if err := parseXMLAction(e.DoubleClickAction, &r.DoubleClickAction); err != nil {
+		return r, err
+	}
+	if err := parseXMLAction(e.ShiftDoubleClick, &r.ShiftDoubleClickAction); err != nil {
+		return r, err
+	}
+
+	if e.TwoFactorKey != "" {
+		key, err := DecodeOTPSecret(e.TwoFactorKey)
+		if err != nil {
+			return r, fmt.Errorf("invalid two factor key of '%s' - %s", r.Title, err.Error())
+		}
+		r.TwoFactorKey = key
+		r.TOTPStartTime = parseXMLTime(e.TOTPStartTime)
+		r.TOTPTimeStep = byte(e.TOTPTimeStep)
+		r.TOTPLength = byte(e.TOTPLength)
+	}
+
+	if h := e.History; h != nil {
+		var entries []PasswordHistoryEntry
+		if h.Entries != nil {
+			for _, old := range h.Entries.Entries {
+				entries = append(entries, PasswordHistoryEntry{Time: parseXMLTime(old.Changed), Password: old.OldPassword.text()})
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Time.Before(entries[j].Time)
+		})
+		r.SetPasswordHistory(entries)
+		r.SetPasswordHistorySettings(h.Status != 0, h.Max)
+	}
+
+	if e.PasswordPolicy != nil {
+		r.PasswordPolicy = e.PasswordPolicy.policy().String()
+	}
+
+	return r, nil
+}
+
+func newXMLPolicy(p PasswordPolicy, named bool) xmlPolicy {
+	flag := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	res := xmlPolicy{
+		Length:             p.Length,
+		UseLowercase:       flag(p.UseLowercase),
+		UseUppercase:       flag(p.UseUppercase),
+		UseDigits:          flag(p.UseDigits),
+		UseSymbols:         flag(p.UseSymbols),
+		UseHexDigits:       flag(p.UseHexDigits),
+		UseEasyVision:      flag(p.UseEasyVision),
+		MakePronounceable:  flag(p.MakePronounceable),
+		LowercaseMinLength: p.LowercaseMinLength,
+		UppercaseMinLength: p.UppercaseMinLength,
+		DigitMinLength:     p.DigitMinLength,
+		SymbolMinLength:    p.SymbolMinLength,
+	}
+	if named {
+		res.Name = &xmlText{Value: p.Name}
+		res.Symbols = newXMLText(p.Symbols)
+	}
+	return res
+}
+
+func (p xmlPolicy) policy() PasswordPolicy {
+	return PasswordPolicy{
+		Name:               p.Name.text(),
+		UseLowercase:       p.UseLowercase != 0,
+		UseUppercase:       p.UseUppercase != 0,
+		UseDigits:          p.UseDigits != 0,
+		UseSymbols:         p.UseSymbols != 0,
+		UseHexDigits:       p.UseHexDigits != 0,
+		UseEasyVision:      p.UseEasyVision != 0,
+		MakePronounceable:  p.MakePronounceable != 0,
+		Length:             p.Length,
+		LowercaseMinLength: p.LowercaseMinLength,
+		UppercaseMinLength: p.UppercaseMinLength,
+		DigitMinLength:     p.DigitMinLength,
+		SymbolMinLength:    p.SymbolMinLength,
+		Symbols:            p.Symbols.text(),
+	}
+}
+
+// newXMLText returns nil for the empty strings, so that the element is omitted
+func newXMLText(s string) *xmlText {
+	if s == "" {
+		return nil
+	}
+	return &xmlText{Value: s}
+}
+
+func (t *xmlText) text() string {
+	if t == nil {
+		return ""
+	}
+	return t.Value
+}
+
+func xmlTime(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return ""
+	}
+	return t.Local().Format(xmlTimeFormat)
+}
+
+// parseXMLTime parses the local times written by the desktop client and the RFC 3339 ones
+func parseXMLTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation(xmlTimeFormat, s, time.Local); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+func decodeXMLUUID(s string, id *[16]byte) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	u := uuid.Parse(s)
+	if u == nil {
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != 16 {
+			return fmt.Errorf("invalid uuid '%s'", s)
+		}
+		u = b
+	}
+	copy(id[:], u)
+	return nil
+}
+
+func parseXMLAction(s string, action *[2]byte) error {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid double click action '%s'", s)
+	}
+	binary.LittleEndian.PutUint16(action[:], uint16(v))
+	return nil
+}
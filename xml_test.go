@@ -0,0 +1,133 @@
+package pwsafe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMLRoundTrip(t *testing.T) {
+	db := NewV3("vault", "password")
+	db.Description = "the team vault"
+	db.PasswordPolicy = "0103pin200000600000000600000"
+	assert.Nil(t, db.AddEmptyGroup("Infra.Prod"))
+
+	rec := Record{
+		Title:              "mail",
+		Group:              "Web.Personal",
+		Username:           "me",
+		Password:           "<s3cret]]>",
+		URL:                "https://mail.example.com",
+		Notes:              "first line\nsecond line",
+		Email:              "me@example.com",
+		PasswordPolicy:     "b000010002000000003",
+		PasswordPolicyName: "pin",
+		TwoFactorKey:       []byte("12345678901234567890"),
+		TOTPLength:         8,
+		TOTPTimeStep:       60,
+		DoubleClickAction:  [2]byte{0x05, 0x00},
+		ProtectedEntry:     1,
+	}
+	rec.PasswordExpiryInterval = [4]byte{90, 0, 0, 0}
+	rec.PasswordExpiry = time.Unix(1600000000, 0)
+	rec.SetPasswordModified(time.Unix(1500000000, 0))
+	rec.SetPasswordHistory([]PasswordHistoryEntry{
+		{Time: time.Unix(1400000000, 0), Password: "old one"},
+		{Time: time.Unix(1500000000, 0), Password: "old two"},
+	})
+	rec.SetPasswordHistorySettings(true, 5)
+	db.SetRecord(rec)
+	rec, _ = db.GetRecord("mail")
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteXML(db, &buf))
+	assert.Equal(t, true, strings.Contains(buf.String(), "<![CDATA[first line\nsecond line]]>"))
+
+	assertSchemaElements(t, buf.String())
+
+	header, records, err := ReadXML(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, db.PasswordPolicy, header.PasswordPolicy)
+	assert.Equal(t, []string{"Infra.Prod"}, header.EmptyGroups)
+
+	assert.Equal(t, 1, len(records))
+	got := records[0]
+	assert.Equal(t, rec.UUID, got.UUID)
+	assert.Equal(t, rec.Password, got.Password)
+	assert.Equal(t, rec.Notes, got.Notes)
+	assert.Equal(t, rec.PasswordHistory, got.PasswordHistory)
+	assert.Equal(t, rec.PasswordModTime, got.PasswordModTime)
+	assert.Equal(t, rec.PasswordPolicy, got.PasswordPolicy)
+	assert.Equal(t, rec.TwoFactorKey, got.TwoFactorKey)
+	assert.Equal(t, rec.PasswordExpiryInterval, got.PasswordExpiryInterval)
+	assert.Equal(t, rec.DoubleClickAction, got.DoubleClickAction)
+	assert.Equal(t, rec.CreateTime.Unix(), got.CreateTime.Unix())
+	assert.Equal(t, rec.PasswordExpiry.Unix(), got.PasswordExpiry.Unix())
+
+	enabled, max := got.PasswordHistorySettings()
+	assert.Equal(t, true, enabled)
+	assert.Equal(t, 5, max)
+}
+
+func TestXMLWhoSaved(t *testing.T) {
+	db := NewV3("vault", "password")
+	db.LastSaveUser = []byte("me")
+	db.LastSaveHost = []byte("laptop")
+	db.LastSaveBy = []byte("pwsafe")
+	db.SetRecord(Record{Title: "mail", Password: "s3cret"})
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteXML(db, &buf))
+	assert.Equal(t, true, strings.Contains(buf.String(), `WhoSaved="me on laptop"`))
+	assertSchemaElements(t, buf.String())
+
+	header, _, err := ReadXML(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("me"), header.LastSaveUser)
+	assert.Equal(t, []byte("laptop"), header.LastSaveHost)
+	assert.Equal(t, []byte("pwsafe"), header.LastSaveBy)
+}
+
+func TestReadXMLInvalid(t *testing.T) {
+	_, _, err := ReadXML(strings.NewReader("<passwordsafe><entry><uuid>nope</uuid></entry></passwordsafe>"))
+	assert.NotNil(t, err)
+}
+
+// xsdElements are the elements of the desktop client schema (pwsafe.xsd)
+var xsdElements = strings.Fields(`
+	passwordsafe NumberHashIterations Preferences NamedPasswordPolicies Policy EmptyGroups EGName
+	PWName PWLength PWUseLowercase PWUseUppercase PWUseDigits PWUseSymbols PWUseHexDigits
+	PWUseEasyVision PWMakePronounceable PWLowercaseMinLength PWUppercaseMinLength
+	PWDigitMinLength PWSymbolMinLength symbols
+	entry group title username password twofactorkey totpconfig totpstarttime totptimestep
+	totplength url autotype notes uuid ctimex atimex xtimex pmtimex rmtimex xtime_interval
+	pwhistory status max num history_entries history_entry changedx oldpassword
+	runcommand dca shiftdca email protected kbshortcut PasswordPolicy PasswordPolicyName
+`)
+
+// assertSchemaElements checks that the document holds only elements of the schema
+func assertSchemaElements(t *testing.T, doc string) {
+	known := make(map[string]bool, len(xsdElements))
+	for _, el := range xsdElements {
+		known[el] = true
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if !assert.Nil(t, err) {
+			return
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			assert.True(t, known[el.Name.Local], "element <%s> is not in the schema", el.Name.Local)
+		}
+	}
+}